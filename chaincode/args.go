@@ -0,0 +1,239 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Types of the arguments of a function.
+const (
+	ArgString  = "string"
+	ArgNumber  = "number"
+	ArgInteger = "integer"
+	// ArgList is a list of strings passed as one comma separated argument
+	ArgList = "list"
+	// ArgVariadic is a list of strings passed as the remaining arguments
+	ArgVariadic = "variadic"
+	// ArgObject is a JSON object passed as one argument
+	ArgObject = "object"
+)
+
+// ArgField describes one positional argument of a function. Default is
+// passed when the field is missing from a JSON object argument. The values of
+// Sensitive fields are never logged. Deprecated fields are still accepted but
+// will be rejected by a later version. Named fields can only be passed in a
+// JSON object argument, for functions with too many settings to pass them by
+// position.
+type ArgField struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Required   bool   `json:"required,omitempty"`
+	Default    string `json:"default,omitempty"`
+	Sensitive  bool   `json:"sensitive,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	Named      bool   `json:"named,omitempty"`
+}
+
+// parseArgs returns the positional arguments of a call of a function with the
+// given schema. A single JSON object argument is converted using the schema,
+// and positional arguments are checked against it.
+func parseArgs(schema []ArgField, args []string) ([]string, error) {
+
+	if len(schema) == 0 {
+		return args, nil
+	}
+
+	fields, isObject := objectArg(schema, args)
+	if isObject {
+		return objectArgs(schema, fields)
+	}
+
+	return args, checkPositionalArgs(schema, args)
+}
+
+// objectArg decodes a single JSON object argument. Searches also accept a
+// JSON filter as their first positional argument, so for them the object is
+// only taken as named arguments when all of its fields are in the schema.
+func objectArg(schema []ArgField, args []string) (map[string]json.RawMessage, bool) {
+
+	if len(args) != 1 || !strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal([]byte(args[0]), &fields)
+	if err != nil {
+		// Reported by objectArgs, unless the object is a search filter
+		if schema[0].Type == ArgObject {
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if schema[0].Type == ArgObject {
+		for name := range fields {
+			if argField(schema, name) == nil {
+				return nil, false
+			}
+		}
+	}
+
+	return fields, true
+}
+
+func argField(schema []ArgField, name string) *ArgField {
+	for i := range schema {
+		if schema[i].Name == name {
+			return &schema[i]
+		}
+	}
+	return nil
+}
+
+// objectArgs converts named arguments to positional ones. Missing fields get
+// their default, or are left empty so the function applies its own. Trailing
+// missing fields are dropped, as a positional caller would.
+func objectArgs(schema []ArgField, fields map[string]json.RawMessage) ([]string, error) {
+
+	if fields == nil {
+		return nil, newError(CodeInvalidArgument, "Invalid JSON arguments: expecting an object")
+	}
+
+	for name := range fields {
+		if argField(schema, name) == nil {
+			return nil, newError(CodeInvalidArgument, "Unknown argument "+name+". Expecting "+argNames(schema))
+		}
+	}
+
+	var args []string
+	provided := 0
+	for _, field := range schema {
+		raw, found := fields[field.Name]
+		if !found || string(raw) == "null" {
+			if field.Required {
+				return nil, newError(CodeInvalidArgument, "Missing argument "+field.Name)
+			}
+			if field.Type != ArgVariadic {
+				args = append(args, field.Default)
+			}
+			if field.Default != "" {
+				provided = len(args)
+			}
+			continue
+		}
+
+		values, err := decodeArg(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, values...)
+		provided = len(args)
+	}
+
+	return args[:provided], nil
+}
+
+// decodeArg returns the positional form of a JSON value.
+func decodeArg(field ArgField, raw json.RawMessage) ([]string, error) {
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	switch field.Type {
+	case ArgString:
+		var value string
+		if decoder.Decode(&value) != nil {
+			return nil, invalidArg(field, "expecting a string")
+		}
+		return []string{value}, nil
+	case ArgNumber, ArgInteger:
+		var value json.Number
+		if decoder.Decode(&value) != nil || raw[0] == '"' {
+			return nil, invalidArg(field, "expecting a "+field.Type)
+		}
+		err := checkArgValue(field, value.String())
+		if err != nil {
+			return nil, err
+		}
+		return []string{value.String()}, nil
+	case ArgList, ArgVariadic:
+		var values []string
+		if decoder.Decode(&values) != nil {
+			return nil, invalidArg(field, "expecting a list of strings")
+		}
+		if field.Type == ArgList {
+			return []string{strings.Join(values, ",")}, nil
+		}
+		return values, nil
+	case ArgObject:
+		var value map[string]interface{}
+		if decoder.Decode(&value) != nil {
+			return nil, invalidArg(field, "expecting an object")
+		}
+		return []string{string(raw)}, nil
+	}
+	return nil, invalidArg(field, "unsupported type "+field.Type)
+}
+
+// checkPositionalArgs checks the number of positional arguments, the syntax
+// of numbers and that Named fields are not passed by position.
+func checkPositionalArgs(schema []ArgField, args []string) error {
+
+	for i, field := range schema {
+		if field.Type == ArgVariadic {
+			break
+		}
+
+		if i >= len(args) {
+			if field.Required {
+				return newError(CodeInvalidArgument, "Missing argument "+field.Name+". Expecting "+argNames(schema))
+			}
+			continue
+		}
+
+		if args[i] == "" {
+			continue
+		}
+
+		if field.Named {
+			return newError(CodeInvalidArgument, "Argument "+field.Name+" can only be passed by name, in a JSON object argument")
+		}
+
+		err := checkArgValue(field, args[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkArgValue(field ArgField, value string) error {
+
+	switch field.Type {
+	case ArgNumber:
+		_, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return invalidArg(field, "expecting a number")
+		}
+	case ArgInteger:
+		_, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return invalidArg(field, "expecting an integer")
+		}
+	}
+	return nil
+}
+
+func invalidArg(field ArgField, reason string) error {
+	return newError(CodeInvalidArgument, "Invalid argument "+field.Name+": "+reason)
+}
+
+func argNames(schema []ArgField) string {
+	var names []string
+	for _, field := range schema {
+		names = append(names, field.Name)
+	}
+	return strings.Join(names, ", ")
+}
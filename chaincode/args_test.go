@@ -0,0 +1,90 @@
+package chaincode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	t.Log("Test JSON object arguments are converted to positional ones")
+	tests := []struct {
+		function string
+		args     []string
+		expected []string
+	}{
+		{"createWallet", []string{`{"walletId":"w1","mobileHash":"h1"}`},
+			[]string{"w1", "h1", "", DefaultAction, DefaultActionEntityId, DefaultCustomer}},
+		{"createWallet", []string{`{"walletId":"w1","mobileHash":"h1","amount":12.50,"customer":"acme"}`},
+			[]string{"w1", "h1", "12.50", DefaultAction, DefaultActionEntityId, "acme"}},
+		{"spendCoins", []string{`{"walletId":"w1","amount":5,"action":"order","actionEntityId":"1"}`},
+			[]string{"w1", "5", "order", "1", DefaultCustomer}},
+		{"getEndorsementPolicy", []string{`{"target":"treasure"}`}, []string{"treasure"}},
+		{"getMigrationPage", []string{`{"docType":"wallet","pageSize":10}`}, []string{"wallet", "", "10"}},
+		{"setEndorsementPolicy", []string{`{"target":"wallet","id":"w1","orgs":["Org1MSP","Org2MSP"]}`},
+			[]string{"wallet", "w1", "Org1MSP", "Org2MSP"}},
+		{"setOptions", []string{`{"registration":100,"adminMSPs":["Org1MSP","Org2MSP"]}`},
+			[]string{"100", DefaultCustomer, "", "", "", "Org1MSP,Org2MSP"}},
+		{"searchWalletTransactions", []string{`{"filter":{"walletId":"w1"},"size":5}`},
+			[]string{`{"walletId":"w1"}`, "1", "5"}},
+		// A JSON search filter stays a positional argument
+		{"searchWalletTransactions", []string{`{"walletId":"w1"}`}, []string{`{"walletId":"w1"}`}},
+		// Positional arguments are kept as they are
+		{"purchaseCoins", []string{"w1", "5", "order", "1"}, []string{"w1", "5", "order", "1"}},
+		{"getTreasure", []string{}, []string{}},
+	}
+
+	for _, test := range tests {
+		args, err := parseArgs(registry[test.function].Args, test.args)
+		ok(t, err)
+		equals(t, test.expected, args)
+	}
+
+	// ---- Negative Cases ----
+	errorTests := []struct {
+		function string
+		args     []string
+		message  string
+	}{
+		{"createWallet", []string{`{"mobileHash":"h1"}`}, "Missing argument walletId"},
+		{"createWallet", []string{`{"walletId":"w1","mobileHash":"h1","amount":"10"}`}, "Invalid argument amount: expecting a number"},
+		{"createWallet", []string{`{"walletId":1,"mobileHash":"h1"}`}, "Invalid argument walletId: expecting a string"},
+		{"createWallet", []string{`{"walletId":"w1","mobileHash":"h1","wallet":"w2"}`}, "Unknown argument wallet"},
+		{"createWallet", []string{`{"walletId":`}, "Invalid JSON arguments"},
+		{"setOptions", []string{`{"registration":1,"approvalThreshold":1.5}`}, "Invalid argument approvalThreshold: expecting an integer"},
+		{"setOptions", []string{`{"registration":1,"adminMSPs":"Org1MSP"}`}, "Invalid argument adminMSPs: expecting a list of strings"},
+		{"setOptions", []string{"1", DefaultCustomer, "", "", "", "Org1MSP"}, "Argument adminMSPs can only be passed by name"},
+		{"searchWallets", []string{`{"filter":"mobileHash"}`}, "Invalid argument filter: expecting an object"},
+		{"purchaseCoins", []string{"w1", "5"}, "Missing argument action. Expecting walletId, amount, action, actionEntityId, customer"},
+		{"purchaseCoins", []string{"w1", "five", "order", "1"}, "Invalid argument amount: expecting a number"},
+		{"searchWallets", []string{"", "first", "10"}, "Invalid argument page: expecting an integer"},
+	}
+
+	for _, test := range errorTests {
+		_, err := parseArgs(registry[test.function].Args, test.args)
+		assert(t, err != nil && strings.HasPrefix(err.Error(), test.message),
+			"%s%q: expected %q, got %v", test.function, test.args, test.message, err)
+	}
+}
+
+func TestInvokeWithObjectArgs(t *testing.T) {
+	t.Log("Test calling functions with a JSON object argument")
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, 100).build()
+
+	response := f.invoke("createWallet", `{"walletId":"w2","mobileHash":"h2","amount":20,"action":"registration","actionEntityId":"w2","customer":"acme"}`)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, float64(20), f.wallet("w2").Amount)
+
+	response = f.invoke("purchaseCoins", `{"walletId":"`+defaultWalletID+`","amount":30,"action":"order","actionEntityId":"1"}`)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, float64(130), f.wallet(defaultWalletID).Amount)
+
+	response = f.invoke("searchWalletTransactions", `{"filter":{"walletId":"w2"}}`)
+	equals(t, int32(200), response.GetStatus())
+	assert(t, strings.Contains(string(response.GetPayload()), `"customer":"acme"`), "unexpected search result %s", response.GetPayload())
+
+	// ---- Negative Cases ----
+	response = f.invoke("spendCoins", `{"walletId":"`+defaultWalletID+`","amount":"30","action":"order","actionEntityId":"2"}`)
+	equals(t, int32(400), response.GetStatus())
+	equals(t, "Invalid argument amount: expecting a number", response.GetMessage())
+	equals(t, float64(130), f.wallet(defaultWalletID).Amount)
+}
@@ -0,0 +1,197 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// benchmarkSizes are the numbers of wallets on the ledger of the benchmarks.
+// The largest one needs a few hundred megabytes and is skipped with -short.
+var benchmarkSizes = []int{10000, 100000, 1000000}
+
+// meteredStub counts the bytes a transaction writes to the ledger.
+type meteredStub struct {
+	*invokerStub
+	written int64
+}
+
+// PutState updates existing keys in place. The MockStub would search its
+// sorted key list for them, which does not happen on a peer; new keys still
+// pay for that search.
+func (s *meteredStub) PutState(key string, value []byte) error {
+	s.written += int64(len(key) + len(value))
+	if _, found := s.State[key]; found {
+		s.State[key] = value
+		return nil
+	}
+	return s.invokerStub.PutState(key, value)
+}
+
+// benchmarkInvoke runs function b.N times in transactions of their own.
+// Unlike fixture.invoke it does not snapshot the ledger, whose size would
+// dominate the measurement. args returns the arguments of iteration i.
+func benchmarkInvoke(b *testing.B, f *fixture, function string, args func(i int) []string) {
+	stub := &meteredStub{invokerStub: f.invokerStub}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		byteArgs := [][]byte{[]byte(function)}
+		for _, arg := range args(i) {
+			byteArgs = append(byteArgs, []byte(arg))
+		}
+
+		txID := f.nextTxID()
+		stub.args = byteArgs
+		stub.MockTransactionStart(txID)
+		response := stub.cc.Invoke(stub)
+		stub.MockTransactionEnd(txID)
+		if response.GetStatus() != shim.OK {
+			b.Fatalf("%s returned %d: %s", function, response.GetStatus(), response.GetMessage())
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(stub.written)/float64(b.N), "written-B/op")
+}
+
+// seedWallets writes n wallets of 1000 coins directly into the MockStub.
+// PutState keeps the MockStub keys in a sorted list, which takes quadratic
+// time to fill, so the list is built once from the sorted keys.
+func seedWallets(b *testing.B, f *fixture, n int) {
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		wallet := Wallet{
+			ObjectType:    WalletObjectType,
+			SchemaVersion: schemaVersion(WalletObjectType),
+			ID:            benchmarkWalletID(i),
+			Amount:        1000,
+			MobileHash:    "hash" + strconv.Itoa(i),
+		}
+
+		key, err := f.CreateCompositeKey(WalletObjectType, []string{wallet.ID})
+		ok(b, err)
+
+		value, err := json.Marshal(wallet)
+		ok(b, err)
+
+		f.State[key] = value
+		keys = append(keys, key)
+	}
+
+	for elem := f.Keys.Front(); elem != nil; elem = elem.Next() {
+		keys = append(keys, elem.Value.(string))
+	}
+	sort.Strings(keys)
+
+	f.Keys.Init()
+	for _, key := range keys {
+		f.Keys.PushBack(key)
+	}
+}
+
+func benchmarkWalletID(i int) string {
+	return "wallet" + strconv.Itoa(i)
+}
+
+func BenchmarkInvoke(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		if size > 100000 && testing.Short() {
+			continue
+		}
+
+		b.Run("wallets="+strconv.Itoa(size), func(b *testing.B) {
+			f := newFixture(b).withOptions(Options{Customer: DefaultCustomer, Registration: 110, StateDatabase: StateDatabaseLevelDB}).build()
+			seedWallets(b, f, size)
+
+			b.Run("createWallet", func(b *testing.B) {
+				benchmarkInvoke(b, f, "createWallet", func(i int) []string {
+					id := "new" + strconv.Itoa(i) + "-" + strconv.Itoa(f.txCount)
+					return []string{id, "hash", "10", "registration", id}
+				})
+			})
+
+			b.Run("getWallet", func(b *testing.B) {
+				benchmarkInvoke(b, f, "getWallet", func(i int) []string {
+					return []string{benchmarkWalletID(i % size)}
+				})
+			})
+
+			b.Run("purchaseCoins", func(b *testing.B) {
+				benchmarkInvoke(b, f, "purchaseCoins", func(i int) []string {
+					return []string{benchmarkWalletID(i % size), "1", "purchase", strconv.Itoa(f.txCount)}
+				})
+			})
+
+			b.Run("spendCoins", func(b *testing.B) {
+				benchmarkInvoke(b, f, "spendCoins", func(i int) []string {
+					return []string{benchmarkWalletID(i % size), "0.001", "spend", strconv.Itoa(f.txCount)}
+				})
+			})
+
+			b.Run("updateWalletMobileHash", func(b *testing.B) {
+				benchmarkInvoke(b, f, "updateWalletMobileHash", func(i int) []string {
+					return []string{benchmarkWalletID(i % size), "hash" + strconv.Itoa(f.txCount)}
+				})
+			})
+
+			b.Run("searchWalletTransactions", func(b *testing.B) {
+				benchmarkInvoke(b, f, "searchWalletTransactions", func(i int) []string {
+					return []string{`{"walletId":"` + benchmarkWalletID(i%size) + `"}`, "1", "10"}
+				})
+			})
+
+			b.Run("mintCoins", func(b *testing.B) {
+				benchmarkInvoke(b, f, "mintCoins", func(i int) []string {
+					return []string{"1", "mint", strconv.Itoa(f.txCount)}
+				})
+			})
+		})
+	}
+}
+
+func BenchmarkWalletMarshal(b *testing.B) {
+	wallet := Wallet{ObjectType: WalletObjectType, SchemaVersion: schemaVersion(WalletObjectType), ID: "wallet1", Amount: 1000, MobileHash: "hash1"}
+	stub := shim.NewMockStub("bench", new(SmartContract))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		value, err := json.Marshal(wallet)
+		ok(b, err)
+
+		err = unmarshalDocument(stub, value, new(Wallet))
+		ok(b, err)
+	}
+}
+
+func BenchmarkUpgradeDocument(b *testing.B) {
+	legacy := []byte(`{"docType":"wallet","id":"wallet1","amount":1000,"mobileHash":"hash1"}`)
+	stub := shim.NewMockStub("bench", new(SmartContract))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _, err := upgradeDocument(stub, legacy)
+		ok(b, err)
+	}
+}
+
+func BenchmarkCreateCompositeKey(b *testing.B) {
+	f := newFixture(b).build()
+
+	for _, attributes := range [][]string{
+		{"wallet1"},
+		{"wallet1", "purchase", "order-1234567890"},
+	} {
+		b.Run("attributes="+strconv.Itoa(len(attributes)), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := f.CreateCompositeKey(WalletTransactionObjectType, attributes)
+				ok(b, err)
+			}
+		})
+	}
+}
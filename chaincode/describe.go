@@ -0,0 +1,146 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Version is the chaincode version reported by describe. Release builds set
+// it with -ldflags "-X github.com/ninjastack101/hyperladger-chaincode/chaincode.Version=1.2.0".
+var Version = "dev"
+
+// Description is returned by describe. It lists everything a client needs to
+// call the deployed chaincode: its functions, the documents they return and
+// the error codes.
+type Description struct {
+	Version   string             `json:"version"`
+	Functions []Function         `json:"functions"`
+	Documents []DocumentSchema   `json:"documents"`
+	Errors    []ErrorDescription `json:"errors"`
+}
+
+// DocumentSchema describes a JSON document. SchemaVersion is set for the
+// documents stored on the ledger.
+type DocumentSchema struct {
+	Name          string          `json:"name"`
+	SchemaVersion int             `json:"schemaVersion,omitempty"`
+	Fields        []DocumentField `json:"fields"`
+}
+
+// DocumentField is a field of a document. Its type is a JSON schema type;
+// Items is the type of the elements of an array, and Fields the fields of an
+// object or of the objects of an array.
+type DocumentField struct {
+	Name   string          `json:"name"`
+	Type   string          `json:"type"`
+	Items  string          `json:"items,omitempty"`
+	Fields []DocumentField `json:"fields,omitempty"`
+}
+
+// ErrorDescription is an error code with its response status.
+type ErrorDescription struct {
+	Code   ErrorCode `json:"code"`
+	Status int32     `json:"status"`
+}
+
+// documents are the documents returned by the functions, by the name the
+// Returns of a function refers to. Ledger documents are named by docType.
+var documents = []struct {
+	name  string
+	value interface{}
+}{
+	{WalletObjectType, Wallet{}},
+	{WalletTransactionObjectType, WalletTransaction{}},
+	{TreasureObjectType, Treasure{}},
+	{TreasureTransactionObjectType, TreasureTransaction{}},
+	{OptionsObjectType, Options{}},
+	{TreasuryProposalObjectType, TreasuryProposal{}},
+	{PauseObjectType, Pause{}},
+	{WalletNonceObjectType, WalletNonce{}},
+	{"endorsementPolicy", EndorsementPolicy{}},
+	{"migrationPage", MigrationPage{}},
+	{"migrationProgress", MigrationProgress{}},
+}
+
+func (s *SmartContract) describe(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	asBytes, err := json.Marshal(Describe())
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+// Describe returns the description of this build of the chaincode.
+func Describe() *Description {
+
+	var description = new(Description)
+	description.Version = Version
+	description.Functions = Functions()
+
+	for _, document := range documents {
+		description.Documents = append(description.Documents, DocumentSchema{
+			Name:          document.name,
+			SchemaVersion: schemaVersion(document.name),
+			Fields:        structFields(reflect.TypeOf(document.value)),
+		})
+	}
+
+	for code, status := range errorStatus {
+		description.Errors = append(description.Errors, ErrorDescription{Code: code, Status: status})
+	}
+	sort.Slice(description.Errors, func(i, j int) bool { return description.Errors[i].Status < description.Errors[j].Status })
+
+	return description
+}
+
+// structFields returns the JSON fields of a struct type.
+func structFields(t reflect.Type) []DocumentField {
+
+	var fields []DocumentField
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		name := strings.Split(structField.Tag.Get("json"), ",")[0]
+		if name == "-" || structField.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = structField.Name
+		}
+
+		field := DocumentField{Name: name, Type: jsonType(structField.Type)}
+		elem := structField.Type
+		if field.Type == "array" {
+			elem = elem.Elem()
+			field.Items = jsonType(elem)
+		}
+		if elem.Kind() == reflect.Struct {
+			field.Fields = structFields(elem)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
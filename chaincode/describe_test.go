@@ -0,0 +1,64 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	t.Log("Test describe lists the functions, documents and error codes")
+	f := newFixture(t).build()
+
+	response := f.invoke("describe")
+	equals(t, int32(200), response.GetStatus())
+
+	var description = new(Description)
+	ok(t, json.Unmarshal(response.GetPayload(), description))
+	equals(t, Version, description.Version)
+	equals(t, len(registry), len(description.Functions))
+	equals(t, len(errorStatus), len(description.Errors))
+
+	documentNames := map[string]bool{}
+	for _, document := range description.Documents {
+		documentNames[document.Name] = true
+	}
+	for _, name := range []string{WalletObjectType, WalletTransactionObjectType, TreasureObjectType, TreasureTransactionObjectType, OptionsObjectType} {
+		assert(t, documentNames[name], "document %s is not described", name)
+	}
+
+	for _, fn := range description.Functions {
+		returns := strings.TrimPrefix(fn.Returns, "[]")
+		assert(t, returns == "" || documentNames[returns], "%s returns the unknown document %s", fn.Name, fn.Returns)
+		if fn.Name == "createWallet" {
+			equals(t, ArgField{Name: "walletId", Type: ArgString, Required: true}, fn.Args[0])
+			equals(t, ArgField{Name: "customer", Type: ArgString, Default: DefaultCustomer}, fn.Args[5])
+		}
+	}
+
+	wallet := description.Documents[0]
+	equals(t, DocumentSchema{Name: WalletObjectType, SchemaVersion: schemaVersion(WalletObjectType), Fields: []DocumentField{
+		{Name: "docType", Type: "string"},
+		{Name: "schemaVersion", Type: "integer"},
+		{Name: "id", Type: "string"},
+		{Name: "amount", Type: "number"},
+		{Name: "mobileHash", Type: "string"},
+		{Name: "publicKey", Type: "string"},
+		{Name: "closed", Type: "boolean"},
+		{Name: "successorId", Type: "string"},
+		{Name: "customer", Type: "string"},
+	}}, wallet)
+
+	for _, document := range description.Documents {
+		if document.Name != TreasuryProposalObjectType {
+			continue
+		}
+		for _, field := range document.Fields {
+			if field.Name == "approvals" {
+				equals(t, "array", field.Type)
+				equals(t, "object", field.Items)
+				equals(t, "mspId", field.Fields[0].Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// EndorsementPolicy lists the organizations whose peers have to endorse
+// every change of a single key, on top of the chaincode-level policy.
+type EndorsementPolicy struct {
+	Target string   `json:"target"`
+	ID     string   `json:"id"`
+	Orgs   []string `json:"orgs"`
+}
+
+// setEndorsementPolicy replaces the key-level endorsement policy of a wallet,
+// the treasury or the options of a customer. Passing no organizations removes
+// the key-level policy so the chaincode-level policy applies again.
+func (s *SmartContract) setEndorsementPolicy(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 2 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 2"))
+	}
+
+	key, id, err := endorsementKey(stub, args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	asBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if len(asBytes) == 0 {
+		return errorResponse(newError(CodeNotFound, "No "+args[0]+" with id "+id+" found"))
+	}
+
+	var orgs []string
+	for _, org := range args[2:] {
+		orgs = append(orgs, splitList(org)...)
+	}
+
+	var policy []byte
+	if len(orgs) != 0 {
+		ep, err := statebased.NewStateEP(nil)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = ep.AddOrgs(statebased.RoleTypePeer, orgs...)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		policy, err = ep.Policy()
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	err = stub.SetStateValidationParameter(key, policy)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return endorsementPolicyResponse(args[0], id, policy)
+}
+
+func (s *SmartContract) getEndorsementPolicy(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	id := ""
+	if len(args) >= 2 {
+		id = args[1]
+	}
+
+	key, id, err := endorsementKey(stub, args[0], id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	policy, err := stub.GetStateValidationParameter(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return endorsementPolicyResponse(args[0], id, policy)
+}
+
+// endorsementKey resolves the state key of a wallet, treasury or options
+// document. An empty id selects the default treasury or customer.
+func endorsementKey(stub shim.ChaincodeStubInterface, target, id string) (string, string, error) {
+
+	var key string
+	var err error
+	switch target {
+	case WalletObjectType:
+		if id == "" {
+			return "", "", newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting a wallet id")
+		}
+		key, err = stub.CreateCompositeKey(WalletObjectType, []string{id})
+	case TreasureObjectType:
+		if id == "" {
+			id = TreasureID
+		}
+		key, err = stub.CreateCompositeKey(TreasureObjectType, []string{id})
+	case OptionsObjectType:
+		if id == "" {
+			id = DefaultCustomer
+		}
+		key, err = stub.CreateCompositeKey(OptionsObjectType, []string{OptionsID, id})
+	default:
+		return "", "", newError(CodeInvalidArgument, "Invalid endorsement target "+target+". Expecting wallet, treasure or options")
+	}
+
+	return key, id, err
+}
+
+func endorsementPolicyResponse(target, id string, policy []byte) sc.Response {
+
+	var endorsement = new(EndorsementPolicy)
+	endorsement.Target = target
+	endorsement.ID = id
+	endorsement.Orgs = []string{}
+
+	if len(policy) != 0 {
+		ep, err := statebased.NewStateEP(policy)
+		if err != nil {
+			return errorResponse(err)
+		}
+		endorsement.Orgs = ep.ListOrgs()
+	}
+
+	asBytes, err := json.Marshal(endorsement)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
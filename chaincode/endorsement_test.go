@@ -0,0 +1,72 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestEndorsementPolicy(t *testing.T) {
+	t.Log("Test setEndorsementPolicy and getEndorsementPolicy")
+	mockStub := newInvoker(t, shim.NewMockStub("endorsement", new(SmartContract)), "Org1MSP", "admin")
+	response := mockStub.MockInit("1", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+
+	response = mockStub.MockInvoke("2", [][]byte{[]byte("createWallet"),
+		[]byte(defaultWalletID), []byte(defaultMobileHash)})
+	equals(t, int32(200), response.GetStatus())
+
+	tests := []struct {
+		target string
+		id     string
+		orgs   string
+	}{
+		{WalletObjectType, defaultWalletID, "PartnerMSP"},
+		{TreasureObjectType, TreasureID, "AdminMSP"},
+		{OptionsObjectType, DefaultCustomer, "AdminMSP,AuditMSP"},
+	}
+
+	for _, test := range tests {
+		response = mockStub.MockInvoke("3", [][]byte{[]byte("setEndorsementPolicy"),
+			[]byte(test.target), []byte(test.id), []byte(test.orgs)})
+		equals(t, int32(200), response.GetStatus())
+
+		response = mockStub.MockInvoke("4", [][]byte{[]byte("getEndorsementPolicy"),
+			[]byte(test.target), []byte(test.id)})
+		equals(t, int32(200), response.GetStatus())
+
+		var policy = new(EndorsementPolicy)
+		err := json.Unmarshal(response.GetPayload(), policy)
+		ok(t, err)
+		equals(t, test.id, policy.ID)
+		equals(t, splitList(test.orgs), policy.Orgs)
+	}
+
+	// Without organizations the key-level policy is removed
+	response = mockStub.MockInvoke("5", [][]byte{[]byte("setEndorsementPolicy"),
+		[]byte(WalletObjectType), []byte(defaultWalletID)})
+	equals(t, int32(200), response.GetStatus())
+
+	var policy = new(EndorsementPolicy)
+	err := json.Unmarshal(response.GetPayload(), policy)
+	ok(t, err)
+	equals(t, []string{}, policy.Orgs)
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestEndorsementPolicyNegative(t *testing.T) {
+	t.Log("Test setEndorsementPolicy Negative")
+	mockStub := newInvoker(t, shim.NewMockStub("endorsement", new(SmartContract)), "Org1MSP", "admin")
+	response := mockStub.MockInit("1", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+
+	response = mockStub.MockInvoke("2", [][]byte{[]byte("setEndorsementPolicy"),
+		[]byte(WalletObjectType), []byte("unknown_wallet_id"), []byte("PartnerMSP")})
+	equals(t, int32(404), response.GetStatus())
+
+	response = mockStub.MockInvoke("3", [][]byte{[]byte("setEndorsementPolicy"),
+		[]byte("walletTransaction"), []byte("any"), []byte("PartnerMSP")})
+	equals(t, int32(400), response.GetStatus())
+}
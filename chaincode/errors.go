@@ -0,0 +1,93 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// ErrorCode identifies why a call failed. Clients should branch on the code
+// rather than on the message, which is meant for humans and may change.
+type ErrorCode string
+
+// Error codes of the catalog.
+const (
+	CodeInvalidArgument   ErrorCode = "INVALID_ARGUMENT"
+	CodeInsufficientFunds ErrorCode = "INSUFFICIENT_FUNDS"
+	CodeForbidden         ErrorCode = "FORBIDDEN"
+	CodeNotFound          ErrorCode = "NOT_FOUND"
+	CodeDoubleHit         ErrorCode = "DOUBLE_HIT"
+	CodeAlreadyExists     ErrorCode = "ALREADY_EXISTS"
+	CodeTreasuryExhausted ErrorCode = "TREASURY_EXHAUSTED"
+	CodePaused            ErrorCode = "PAUSED"
+	// CodeInternal covers ledger failures and other errors outside the catalog
+	CodeInternal ErrorCode = "INTERNAL"
+)
+
+// errorStatus is the response status of every code. Each code has its own
+// status so that clients which only see the status, such as SDKs reporting
+// a failed endorsement, can still tell the codes apart. DOUBLE_HIT keeps the
+// 409 it has always been returned with.
+var errorStatus = map[ErrorCode]int32{
+	CodeInvalidArgument:   400,
+	CodeInsufficientFunds: 402,
+	CodeForbidden:         403,
+	CodeNotFound:          404,
+	CodeDoubleHit:         409,
+	CodeAlreadyExists:     412,
+	CodeTreasuryExhausted: 422,
+	CodeInternal:          500,
+	CodePaused:            503,
+}
+
+// Status returns the response status of the code.
+func (code ErrorCode) Status() int32 {
+	status, found := errorStatus[code]
+	if !found {
+		return errorStatus[CodeInternal]
+	}
+	return status
+}
+
+// ErrorCodeOf returns the code of a response status, and false for statuses
+// that are not in the catalog.
+func ErrorCodeOf(status int32) (ErrorCode, bool) {
+	for code, codeStatus := range errorStatus {
+		if codeStatus == status {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// Error is an error of the catalog. It is also the JSON payload of every
+// error response.
+type Error struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// errorResponse returns the response of a failed call. Errors outside the
+// catalog are reported as INTERNAL.
+func errorResponse(err error) sc.Response {
+
+	chaincodeErr, isChaincodeErr := err.(*Error)
+	if !isChaincodeErr {
+		chaincodeErr = newError(CodeInternal, err.Error())
+	}
+
+	payload, _ := json.Marshal(chaincodeErr)
+	return sc.Response{
+		Status:  chaincodeErr.Code.Status(),
+		Message: chaincodeErr.Message,
+		Payload: payload,
+	}
+}
@@ -0,0 +1,64 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestErrorCatalog(t *testing.T) {
+	t.Log("Test every error code has its own status")
+	statuses := map[int32]ErrorCode{}
+	for code, status := range errorStatus {
+		other, found := statuses[status]
+		assert(t, !found, "%s and %s share status %d", code, other, status)
+		statuses[status] = code
+		assert(t, status >= 400, "%s has the success status %d", code, status)
+
+		decoded, found := ErrorCodeOf(status)
+		assert(t, found && decoded == code, "status %d maps to %s, expected %s", status, decoded, code)
+	}
+
+	_, found := ErrorCodeOf(200)
+	assert(t, !found, "status 200 maps to an error code")
+	equals(t, int32(500), ErrorCode("UNKNOWN").Status())
+
+	response := errorResponse(errors.New("ledger unavailable"))
+	equals(t, int32(500), response.GetStatus())
+	equals(t, "ledger unavailable", response.GetMessage())
+	equals(t, `{"code":"INTERNAL","message":"ledger unavailable"}`, string(response.GetPayload()))
+}
+
+func TestErrorPayloads(t *testing.T) {
+	t.Log("Test failed calls answer with their error code")
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, 100).build()
+	f.invoke("purchaseCoins", defaultWalletID, "10", "MAGIC_BOX", "BOX_NUMBER_1")
+
+	tests := []struct {
+		args []string
+		code ErrorCode
+	}{
+		{[]string{"spendCoins", defaultWalletID, "1000", "PREDICTION", "P_NUMBER_1"}, CodeInsufficientFunds},
+		{[]string{"purchaseCoins", defaultWalletID, "1000000000", "MAGIC_BOX", "BOX_NUMBER_2"}, CodeTreasuryExhausted},
+		{[]string{"purchaseCoins", defaultWalletID, "10", "MAGIC_BOX", "BOX_NUMBER_1"}, CodeDoubleHit},
+		{[]string{"purchaseCoins", "unknown", "10", "MAGIC_BOX", "BOX_NUMBER_3"}, CodeNotFound},
+		{[]string{"createWallet", defaultWalletID, defaultMobileHash}, CodeAlreadyExists},
+		{[]string{"spendCoins", defaultWalletID, "-1", "PREDICTION", "P_NUMBER_2"}, CodeInvalidArgument},
+		{[]string{"setOptions", "abc"}, CodeInvalidArgument},
+	}
+
+	for _, test := range tests {
+		response := f.invoke(test.args[0], test.args[1:]...)
+		equals(t, test.code.Status(), response.GetStatus())
+
+		var payload Error
+		ok(t, json.Unmarshal(response.GetPayload(), &payload))
+		equals(t, test.code, payload.Code)
+		equals(t, response.GetMessage(), payload.Message)
+	}
+
+	// ---- Forbidden ----
+	f = newFixture(t).withOptions(Options{Customer: DefaultCustomer, Registration: 1, AdminMSPs: []string{"AdminMSP"}}).build()
+	response := f.invoke("setOptions", "10")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+}
@@ -0,0 +1,151 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// fuzzInvocations seed FuzzInvoke with a valid call of every invoke function.
+// The fuzzer derives the function from the first argument and splits the
+// rest on newlines.
+var fuzzInvocations = [][]string{
+	{"createWallet", "w2", "hash2", "10", "registration", "w2"},
+	{"getWallet", "w1"},
+	{"getWalletByMobileHash", "hash1"},
+	{"searchWallets", "", "1", "10"},
+	{"updateWalletMobileHash", "w1", "hash3"},
+	{"purgeWalletMobile", "w1"},
+	{"setMobileIndexKey"},
+	{"purgeLegacyMobileHashes", "d2FsbGV0AHcxAA=="},
+	{"searchWalletTransactions", `{"walletId":"w1"}`, "1", "10"},
+	{"searchTreasureTransactions", `{"customer":"ninjastack"}`, "1", "10"},
+	{"createTreasure", "100", "other"},
+	{"getTreasure"},
+	{"mintCoins", "100", "mint", "1"},
+	{"burnCoins", "100", "burn", "1"},
+	{"proposeTreasuryOp", "withdraw", "100", "withdraw", "1", "w1"},
+	{"approveTreasuryOp", "tx1"},
+	{"executeTreasuryOp", "tx1"},
+	{"getTreasuryOp", "tx1"},
+	{"purchaseCoins", "w1", "10", "order", "1"},
+	{"spendCoins", "w1", "10", "order", "1"},
+	{"spendCoins", "w1", "10", "order", "1", "ninjastack", "1", "1", "c2lnbmF0dXJl"},
+	{"rotateWalletKey", "w1", "a2V5", "1", "1", "c2lnbmF0dXJl"},
+	{"getWalletNonce", "w1"},
+	{"recoverWallet", "w1", "w2", "LOST_ACCESS"},
+	{"executeSigned", `{"function":"spendCoins","args":["w1","10","order","1","ninjastack","1","1","c2lnbmF0dXJl"]}`, "w2", "1", "w3"},
+	{"setOptions", `{"registration":110,"customer":"ninjastack","maxSupply":1000000000,"approvalLimit":1000,"approvalThreshold":1,"adminMSPs":["Org1MSP"],"proposalTTL":3600,"stateDatabase":"leveldb"}`},
+	{"setOptions", "110", "ninjastack", "1000000000"},
+	{"getOptions", "ninjastack"},
+	{"setEndorsementPolicy", "wallet", "w1", "Org1MSP"},
+	{"getEndorsementPolicy", "wallet", "w1"},
+	{"getMigrationPage", "wallet", "", "10"},
+	{"migrate", "wallet", "d2FsbGV0AHcxAA=="},
+	{"describe"},
+	{"pauseContract", "ninjastack", "spendCoins", "incident"},
+	{"unpauseContract", ""},
+	{"getPauses"},
+}
+
+// FuzzInvoke checks that no arguments make an invoke function panic, answer
+// with an unexpected status or break the supply of the treasury.
+func FuzzInvoke(f *testing.F) {
+	for _, invocation := range fuzzInvocations {
+		f.Add(invocation[0], strings.Join(invocation[1:], "\n"))
+	}
+
+	f.Fuzz(func(t *testing.T, function, args string) {
+		fx := newFixture(t).withWallet("w1", "hash1", 100).build()
+
+		var splitArgs []string
+		if args != "" {
+			splitArgs = strings.Split(args, "\n")
+		}
+
+		response := fx.invoke(function, splitArgs...)
+		status := response.GetStatus()
+		_, isErrorCode := ErrorCodeOf(status)
+		assert(t, status == 200 || isErrorCode, "%s returned status %d", function, status)
+		assert(t, !strings.HasPrefix(response.GetMessage(), "Internal error in"), "%s panicked", function)
+
+		checkSupply(t, fx)
+	})
+}
+
+// checkSupply checks that the treasury and the wallets hold exactly the
+// total supply and that no balance is negative.
+func checkSupply(t *testing.T, f *fixture) {
+	treasure := f.treasure()
+	assert(t, treasure.Balance >= 0, "treasury has a negative balance %v", treasure.Balance)
+
+	sum := treasure.Balance
+	for _, value := range f.State {
+		var wallet Wallet
+		if json.Unmarshal(value, &wallet) != nil || wallet.ObjectType != WalletObjectType {
+			continue
+		}
+
+		assert(t, wallet.Amount >= 0, "wallet %s has a negative balance %v", wallet.ID, wallet.Amount)
+		sum += wallet.Amount
+	}
+
+	// Amounts are floats, so moving a tiny amount next to a large balance
+	// rounds; anything beyond that is a bug.
+	tolerance := math.Abs(treasure.TotalSupply) * 1e-12
+	assert(t, math.Abs(sum-treasure.TotalSupply) <= tolerance,
+		"treasury and wallets hold %v of a supply of %v", sum, treasure.TotalSupply)
+}
+
+func FuzzParseAmount(f *testing.F) {
+	for _, seed := range []string{"0", "10", "2.5", "-1", "NaN", "Inf", "1e308", "0x10", "abc", ""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, arg string) {
+		amount, err := parseAmount(arg)
+		if err != nil {
+			return
+		}
+		assert(t, amount >= 0 && !math.IsInf(amount, 0), "parseAmount(%q) accepted %v", arg, amount)
+	})
+}
+
+func FuzzParseSearchFilter(f *testing.F) {
+	for _, seed := range []string{`"walletId":"w1"`, `{"customer":"ninjastack","action":"order"}`, `{}`, ``, `"a":`, `[1]`} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, arg string) {
+		filter, err := parseSearchFilter(arg)
+		if err != nil {
+			return
+		}
+
+		for _, docType := range []string{WalletObjectType, WalletTransactionObjectType, TreasureTransactionObjectType} {
+			query, err := buildSearchQuery(docType, filter, 10, 0)
+			if err != nil {
+				continue
+			}
+			if checkCompositeKeyFilter(docType, filter) == nil {
+				assert(t, query.UseIndex != nil, "query for %v on %s uses no index", filter, docType)
+			}
+			equals(t, docType, query.Selector["docType"])
+		}
+	})
+}
+
+func FuzzParseGenesisConfig(f *testing.F) {
+	for _, seed := range []string{"", "1000\n110", "1000\n110\nreset", `{"treasury":1000,"maxSupply":2000}`, `{"treasury":`, "abc"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, args string) {
+		config, err := parseGenesisConfig(strings.Split(args, "\n"))
+		if err != nil {
+			return
+		}
+		assert(t, config != nil, "parseGenesisConfig(%q) returned no config", args)
+	})
+}
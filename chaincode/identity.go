@@ -0,0 +1,70 @@
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+var errNotAdmin = newError(CodeForbidden, "Invoker is not a member of an admin organization")
+
+// getInvoker returns the MSP ID and the unique client ID of the identity
+// that submitted the transaction.
+func getInvoker(stub shim.ChaincodeStubInterface) (string, string, error) {
+
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		return "", "", err
+	}
+
+	clientID, err := cid.GetID(stub)
+	if err != nil {
+		return "", "", err
+	}
+
+	return mspID, clientID, nil
+}
+
+// checkAdmin fails unless the invoker belongs to one of the adminMSPs of the
+// default customer options. Without admin organizations nobody is an admin;
+// Init sets them on genesis and on the first upgrade of a ledger without them.
+func (s *SmartContract) checkAdmin(stub shim.ChaincodeStubInterface) error {
+
+	optionsBytes, err := s.getOptionsAsByte(stub, DefaultCustomer)
+	if err != nil {
+		return err
+	}
+
+	if len(optionsBytes) == 0 {
+		return errNotAdmin
+	}
+
+	var options = new(Options)
+	err = unmarshalDocument(stub, optionsBytes, options)
+	if err != nil {
+		return err
+	}
+
+	if len(options.AdminMSPs) == 0 {
+		return errNotAdmin
+	}
+
+	mspID, _, err := getInvoker(stub)
+	if err != nil {
+		return err
+	}
+
+	if !containsString(options.AdminMSPs, mspID) {
+		return errNotAdmin
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,327 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Error declaration
+var (
+	errDoubleHit = newError(CodeDoubleHit, "Transaction with given action and action entity already exists for the given wallet id")
+)
+
+type SmartContract struct {
+}
+
+const DefaultTreasureAmount = "210000000"
+const DefaultRegistrationAmount = 110
+const DefaultCustomer = "ninjastack"
+const DefaultAction = "action"
+const DefaultActionEntityId = "action-entity-id"
+
+const OptionsObjectType = "options"
+const WalletObjectType = "wallet"
+const TreasureObjectType = "treasure"
+const WalletTransactionObjectType = "walletTransaction"
+const TreasureTransactionObjectType = "treasureTransaction"
+const TreasuryProposalObjectType = "treasuryProposal"
+const PauseObjectType = "pause"
+const WalletNonceObjectType = "walletNonce"
+const MobileHashIndex = "mobileHash~walletId"
+const OptionsID = "Options"
+const TreasureID = "Treasure"
+
+// GenesisConfig is the JSON form of the Init arguments. Options holds the
+// options per customer; the default customer is created when missing and
+// receives AdminMSPs.
+type GenesisConfig struct {
+	Treasury  float64   `json:"treasury"`
+	MaxSupply float64   `json:"maxSupply,omitempty"`
+	Options   []Options `json:"options,omitempty"`
+	AdminMSPs []string  `json:"adminMSPs,omitempty"`
+	Reset     bool      `json:"reset,omitempty"`
+}
+
+// Init initializes chaincode
+// ========================================
+// Init also runs on every upgrade. Once the treasury exists it
+// leaves the ledger untouched, unless an admin passes the reset flag, so an
+// upgrade with default arguments cannot wipe balances or options.
+//
+// Arguments are either a single JSON GenesisConfig or the legacy positional
+// form: treasury amount, registration amount and an optional "reset".
+func (s *SmartContract) Init(stub shim.ChaincodeStubInterface) sc.Response {
+	_, args := stub.GetFunctionAndParameters()
+	config, err := parseGenesisConfig(args)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	key, err := stub.CreateCompositeKey(TreasureObjectType, []string{TreasureID})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	treasureAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if len(treasureAsBytes) != 0 {
+		if !config.Reset {
+			logger.Info("Treasury already exists, skipping genesis")
+			err = s.bootstrapAdmins(stub, config)
+			if err != nil {
+				return errorResponse(err)
+			}
+			return shim.Success(nil)
+		}
+
+		err = s.checkAdmin(stub)
+		if err != nil {
+			return errorResponse(err)
+		}
+		logger.Warning("Resetting treasury and options on request of the invoker")
+	}
+
+	err = s.genesis(stub, config, len(treasureAsBytes) != 0)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(nil)
+}
+
+func parseGenesisConfig(args []string) (*GenesisConfig, error) {
+
+	var config = new(GenesisConfig)
+	if len(args) > 0 && strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
+		err := json.Unmarshal([]byte(args[0]), config)
+		if err != nil {
+			return nil, newError(CodeInvalidArgument, "Invalid genesis config: "+err.Error())
+		}
+		return config, nil
+	}
+
+	config.Treasury, _ = strconv.ParseFloat(DefaultTreasureAmount, 64)
+	if len(args) > 0 && args[0] != "" {
+		value, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return nil, newError(CodeInvalidArgument, err.Error())
+		}
+		config.Treasury = value
+	}
+
+	var registration float64 = DefaultRegistrationAmount
+	if len(args) >= 2 && args[1] != "" {
+		value, err := parseAmount(args[1])
+		if err != nil {
+			return nil, err
+		}
+		registration = value
+	}
+	config.Options = []Options{{Customer: DefaultCustomer, Registration: registration}}
+
+	config.Reset = len(args) >= 3 && args[2] == "reset"
+	return config, nil
+}
+
+// genesis writes the treasury and the options of the config. On a reset the
+// options keep the stored value of every field the config leaves out, and
+// the treasury balance is replaced while the coins held by wallets stay in
+// the total supply. Wallets and transactions are not touched.
+func (s *SmartContract) genesis(stub shim.ChaincodeStubInterface, config *GenesisConfig, reset bool) error {
+
+	if config.Treasury < 0 {
+		return newError(CodeInvalidArgument, "treasury amount must not be negative")
+	}
+
+	var options = config.Options
+	hasDefault := false
+	for _, o := range options {
+		if o.Customer == DefaultCustomer {
+			hasDefault = true
+		}
+	}
+	if !hasDefault {
+		options = append(options, Options{Customer: DefaultCustomer})
+	}
+
+	var maxSupply float64
+	var err error
+	for i := range options {
+		var o = &options[i]
+		if o.Customer == "" {
+			return newError(CodeInvalidArgument, "customer is required for every genesis option")
+		}
+		if o.Registration < 0 {
+			return newError(CodeInvalidArgument, "registration of customer "+o.Customer+" must not be negative")
+		}
+
+		stored := false
+		if reset {
+			stored, err = mergeStoredOptions(stub, o)
+			if err != nil {
+				return err
+			}
+		}
+
+		o.ObjectType = OptionsObjectType
+		o.SchemaVersion = schemaVersion(OptionsObjectType)
+		if o.Customer == DefaultCustomer {
+			if !hasDefault && !stored {
+				o.Registration = DefaultRegistrationAmount
+			}
+			if config.MaxSupply != 0 {
+				o.MaxSupply = config.MaxSupply
+			}
+			if len(config.AdminMSPs) != 0 {
+				o.AdminMSPs = config.AdminMSPs
+			}
+			if len(o.AdminMSPs) == 0 {
+				o.AdminMSPs, err = initAdminMSPs(stub, config)
+				if err != nil {
+					return err
+				}
+			}
+			err = validateApprovalThreshold(o)
+			if err != nil {
+				return err
+			}
+			maxSupply = o.MaxSupply
+		}
+	}
+
+	if reset {
+		err = s.resetTreasure(stub, config.Treasury, maxSupply)
+	} else if maxSupply > 0 && config.Treasury > maxSupply {
+		err = newError(CodeInvalidArgument, "treasury amount exceeds the maximum supply of "+strconv.FormatFloat(maxSupply, 'f', -1, 64))
+	} else {
+		_, err = s.putGenesisTreasure(stub, TreasureID, config.Treasury)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range options {
+		_, err = putOptions(stub, &options[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeStoredOptions fills the fields of o that a reset config leaves out
+// with the stored options of the same customer, so that a reset cannot drop
+// the governance settings. It reports whether options were stored.
+func mergeStoredOptions(stub shim.ChaincodeStubInterface, o *Options) (bool, error) {
+
+	key, err := stub.CreateCompositeKey(OptionsObjectType, []string{OptionsID, o.Customer})
+	if err != nil {
+		return false, err
+	}
+
+	asBytes, err := stub.GetState(key)
+	if err != nil {
+		return false, err
+	}
+
+	if len(asBytes) == 0 {
+		return false, nil
+	}
+
+	var stored = new(Options)
+	err = unmarshalDocument(stub, asBytes, stored)
+	if err != nil {
+		return false, err
+	}
+
+	if o.Registration == 0 {
+		o.Registration = stored.Registration
+	}
+	if o.MaxSupply == 0 {
+		o.MaxSupply = stored.MaxSupply
+	}
+	if o.ApprovalLimit == 0 {
+		o.ApprovalLimit = stored.ApprovalLimit
+	}
+	if o.ApprovalThreshold == 0 {
+		o.ApprovalThreshold = stored.ApprovalThreshold
+	}
+	if len(o.AdminMSPs) == 0 {
+		o.AdminMSPs = stored.AdminMSPs
+	}
+	if o.ProposalTTL == 0 {
+		o.ProposalTTL = stored.ProposalTTL
+	}
+	if o.StateDatabase == "" {
+		o.StateDatabase = stored.StateDatabase
+	}
+
+	return true, nil
+}
+
+// initAdminMSPs returns the admin organizations set by Init: the adminMSPs
+// of the config, or else the organization of the identity running Init.
+func initAdminMSPs(stub shim.ChaincodeStubInterface, config *GenesisConfig) ([]string, error) {
+
+	if len(config.AdminMSPs) != 0 {
+		return config.AdminMSPs, nil
+	}
+
+	mspID, _, err := getInvoker(stub)
+	if err != nil {
+		return nil, err
+	}
+	return []string{mspID}, nil
+}
+
+// bootstrapAdmins sets the admin organizations of a ledger created before
+// they were enforced, so that the upgrade which enforces them does not lock
+// every invoker out. Ledgers with admin organizations are left untouched.
+func (s *SmartContract) bootstrapAdmins(stub shim.ChaincodeStubInterface, config *GenesisConfig) error {
+
+	optionsBytes, err := s.getOptionsAsByte(stub, DefaultCustomer)
+	if err != nil {
+		return err
+	}
+
+	var options = new(Options)
+	if len(optionsBytes) != 0 {
+		err = unmarshalDocument(stub, optionsBytes, options)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(options.AdminMSPs) != 0 {
+		return nil
+	}
+
+	options.ObjectType = OptionsObjectType
+	options.SchemaVersion = schemaVersion(OptionsObjectType)
+	options.Customer = DefaultCustomer
+	if len(optionsBytes) == 0 {
+		options.Registration = DefaultRegistrationAmount
+	}
+
+	options.AdminMSPs, err = initAdminMSPs(stub, config)
+	if err != nil {
+		return err
+	}
+
+	err = validateApprovalThreshold(options)
+	if err != nil {
+		return err
+	}
+
+	logger.Warning("Setting the admin organizations of the ledger to " + strings.Join(options.AdminMSPs, ", "))
+	_, err = putOptions(stub, options)
+	return err
+}
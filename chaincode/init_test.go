@@ -0,0 +1,185 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func getTestTreasure(t *testing.T, mockStub *shim.MockStub) *Treasure {
+	response := mockStub.MockInvoke("get", [][]byte{[]byte("getTreasure")})
+	equals(t, int32(200), response.GetStatus())
+
+	var treasure = new(Treasure)
+	err := json.Unmarshal(response.GetPayload(), treasure)
+	ok(t, err)
+	return treasure
+}
+
+func TestInitUpgrade(t *testing.T) {
+	t.Log("Test Init on upgrade")
+	mockStub := newInvoker(t, shim.NewMockStub("init", new(SmartContract)), "Org1MSP", "admin")
+	response := mockStub.MockInit("1", [][]byte{[]byte("init"), []byte("1000"), []byte("50")})
+	equals(t, int32(200), response.GetStatus())
+	equals(t, float64(1000), getTestTreasure(t, mockStub.MockStub).Balance)
+
+	// An upgrade with default arguments keeps the existing state
+	response = mockStub.MockInit("2", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+	equals(t, float64(1000), getTestTreasure(t, mockStub.MockStub).Balance)
+
+	response = mockStub.MockInvoke("3", [][]byte{[]byte("getOptions")})
+	var options = new(Options)
+	err := json.Unmarshal(response.GetPayload(), options)
+	ok(t, err)
+	equals(t, float64(50), options.Registration)
+	equals(t, []string{"Org1MSP"}, options.AdminMSPs)
+
+	response = mockStub.MockInit("4", [][]byte{[]byte("init"), []byte("2000"), []byte("60"), []byte("reset")})
+	equals(t, int32(200), response.GetStatus())
+	equals(t, float64(2000), getTestTreasure(t, mockStub.MockStub).Balance)
+}
+
+func TestInitBootstrapAdmins(t *testing.T) {
+	t.Log("Test Init sets the admin organizations of a ledger without them")
+	mockStub := shim.NewMockStub("init", new(SmartContract))
+	admin := newInvoker(t, mockStub, "AdminMSP", "admin1")
+	outsider := newInvoker(t, mockStub, "OtherMSP", "user1")
+
+	// A ledger written before admin organizations were enforced
+	mockStub.MockTransactionStart("legacy")
+	_, err := new(SmartContract).putGenesisTreasure(mockStub, TreasureID, 1000)
+	ok(t, err)
+	_, err = putOptions(mockStub, &Options{ObjectType: OptionsObjectType, Customer: DefaultCustomer, Registration: 50})
+	ok(t, err)
+	mockStub.MockTransactionEnd("legacy")
+
+	response := outsider.MockInvoke("1", [][]byte{[]byte("setOptions"), []byte("1")})
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = admin.MockInit("2", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+
+	response = admin.MockInvoke("3", [][]byte{[]byte("setOptions"), []byte("60")})
+	equals(t, int32(200), response.GetStatus())
+
+	// Later upgrades keep the admin organizations
+	response = outsider.MockInit("4", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+
+	response = outsider.MockInvoke("5", [][]byte{[]byte("setOptions"), []byte("1")})
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+	equals(t, float64(1000), getTestTreasure(t, mockStub).Balance)
+}
+
+func TestInitGenesisConfig(t *testing.T) {
+	t.Log("Test Init with a genesis config")
+	mockStub := shim.NewMockStub("init", new(SmartContract))
+	admin := newInvoker(t, mockStub, "AdminMSP", "admin1")
+	config := `{"treasury": 5000, "maxSupply": 10000, "adminMSPs": ["AdminMSP"],
+		"options": [{"customer": "partner", "registration": 20}]}`
+	response := admin.MockInit("1", [][]byte{[]byte("init"), []byte(config)})
+	equals(t, int32(200), response.GetStatus())
+
+	treasure := getTestTreasure(t, mockStub)
+	equals(t, float64(5000), treasure.Balance)
+	equals(t, float64(5000), treasure.TotalSupply)
+
+	tests := []struct {
+		customer     string
+		registration float64
+	}{
+		{"partner", 20},
+		{DefaultCustomer, DefaultRegistrationAmount},
+	}
+
+	for _, test := range tests {
+		response = mockStub.MockInvoke("2", [][]byte{[]byte("getOptions"), []byte(test.customer)})
+		var options = new(Options)
+		err := json.Unmarshal(response.GetPayload(), options)
+		ok(t, err)
+		equals(t, test.registration, options.Registration)
+	}
+
+	response = mockStub.MockInvoke("3", [][]byte{[]byte("getOptions")})
+	var options = new(Options)
+	err := json.Unmarshal(response.GetPayload(), options)
+	ok(t, err)
+	equals(t, []string{"AdminMSP"}, options.AdminMSPs)
+	equals(t, float64(10000), options.MaxSupply)
+
+	response = admin.MockInit("4", [][]byte{[]byte("init"), []byte(`{"treasury": 7000, "reset": true}`)})
+	equals(t, int32(200), response.GetStatus())
+	equals(t, float64(7000), getTestTreasure(t, mockStub).Balance)
+}
+
+func TestInitResetKeepsOptions(t *testing.T) {
+	t.Log("Test a reset keeps the stored options and the coins held by wallets")
+	f := newFixture(t).withAdmins("Org1MSP", "Org2MSP").
+		withOptions(Options{Customer: DefaultCustomer, Registration: 50, ApprovalLimit: 1000, ApprovalThreshold: 2, ProposalTTL: 60, StateDatabase: StateDatabaseLevelDB}).
+		withWallet("w1", "hash1", 100).build()
+
+	response := f.invoke("setOptions", `{"registration":50,"maxSupply":300000000}`)
+	equals(t, int32(200), response.GetStatus())
+	supply := f.treasure().TotalSupply
+
+	response = f.MockInit(f.nextTxID(), [][]byte{[]byte("init"), []byte(`{"treasury": 7000, "reset": true}`)})
+	equals(t, int32(200), response.GetStatus())
+
+	options := f.options(DefaultCustomer)
+	equals(t, []string{"Org1MSP", "Org2MSP"}, options.AdminMSPs)
+	equals(t, 2, options.ApprovalThreshold)
+	equals(t, 1000.0, options.ApprovalLimit)
+	equals(t, int64(60), options.ProposalTTL)
+	equals(t, StateDatabaseLevelDB, options.StateDatabase)
+	equals(t, 300000000.0, options.MaxSupply)
+	equals(t, 50.0, options.Registration)
+
+	// The wallet keeps its coins, which stay in the total supply
+	treasure := f.treasure()
+	equals(t, 7000.0, treasure.Balance)
+	equals(t, 7100.0, treasure.TotalSupply)
+	assert(t, treasure.TotalSupply < supply, "expected the reset to shrink the supply of %v", supply)
+	equals(t, 100.0, f.wallet("w1").Amount)
+
+	// Admin organizations are still enforced
+	response = f.invokeAs(f.as("Org3MSP", "user3"), "setOptions", "1")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invokeAs(f.as("Org3MSP", "user3"), "mintCoins", "10", "MINT", "MINT_NUMBER_1")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	// A reset cannot exceed the maximum supply
+	response = f.MockInit(f.nextTxID(), [][]byte{[]byte("init"), []byte(`{"treasury": 299999901, "reset": true}`)})
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+	equals(t, 7000.0, f.treasure().Balance)
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestInitNegative(t *testing.T) {
+	t.Log("Test Init Negative")
+	mockStub := shim.NewMockStub("init", new(SmartContract))
+	admin := newInvoker(t, mockStub, "AdminMSP", "admin1")
+	outsider := newInvoker(t, mockStub, "OtherMSP", "user1")
+	response := admin.MockInit("1", [][]byte{[]byte("init"), []byte(`{"treasury": 5000, "adminMSPs": ["AdminMSP"]}`)})
+	equals(t, int32(200), response.GetStatus())
+
+	response = outsider.MockInit("2", [][]byte{[]byte("init"), []byte(`{"treasury": 1, "reset": true}`)})
+	equals(t, int32(403), response.GetStatus())
+	equals(t, float64(5000), getTestTreasure(t, mockStub).Balance)
+
+	response = admin.MockInit("3", [][]byte{[]byte("init"), []byte(`{"treasury": "lots"}`)})
+	equals(t, int32(400), response.GetStatus())
+
+	response = admin.MockInit("4", [][]byte{[]byte("init"), []byte("not-a-number")})
+	equals(t, int32(400), response.GetStatus())
+
+	response = admin.MockInit("5", [][]byte{[]byte("init"), []byte("5000"), []byte("-110")})
+	equals(t, int32(400), response.GetStatus())
+
+	response = admin.MockInit("6", [][]byte{[]byte("init"),
+		[]byte(`{"treasury": 5000, "reset": true, "options": [{"customer": "partner", "registration": -110}]}`)})
+	equals(t, int32(400), response.GetStatus())
+}
@@ -0,0 +1,24 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Invoke calls the registered function, wrapped in the middleware pipeline.
+// Functions are added to the registry in registry.go.
+func (s *SmartContract) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+
+	// Retrieve the requested Smart Contract function and arguments
+	function, args := stub.GetFunctionAndParameters()
+
+	fn, found := registry[function]
+	if !found {
+		logger.Warning(fmt.Sprintf("Invoke of unknown function %s", function))
+		return errorResponse(newError(CodeInvalidArgument, "Invalid Smart contract function name."))
+	}
+
+	return fn.call(s, stub, args)
+}
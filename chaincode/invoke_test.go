@@ -0,0 +1,131 @@
+package chaincode
+
+import (
+	"testing"
+)
+
+// TestInvoke runs every function of Invoke on a fresh fixture holding the
+// default wallet, after the setup invocations of the case.
+func TestInvoke(t *testing.T) {
+	t.Log("Test every Invoke function")
+	tests := []struct {
+		name   string
+		setup  [][]string
+		args   []string
+		status int32
+		empty  bool
+	}{
+		{"createWallet", nil, []string{"createWallet", "wallet_2", "hash_2"}, 200, false},
+		{"createWallet with amount", nil, []string{"createWallet", "wallet_2", "hash_2", "10", "SIGNUP", "SIGNUP_1", "partner"}, 200, false},
+		{"createWallet existing", nil, []string{"createWallet", defaultWalletID, "hash_2"}, 412, false},
+		{"createWallet action entity of another wallet", [][]string{{"createWallet", "wallet_2", "hash_2", "10", "SIGNUP", "SIGNUP_1"}},
+			[]string{"createWallet", "wallet_3", "hash_3", "10", "SIGNUP", "SIGNUP_1"}, 200, false},
+		{"createWallet missing args", nil, []string{"createWallet", "wallet_2"}, 400, false},
+		{"createWallet invalid amount", nil, []string{"createWallet", "wallet_2", "hash_2", "many"}, 400, false},
+		{"getWallet", nil, []string{"getWallet", defaultWalletID}, 200, false},
+		{"getWallet not found", nil, []string{"getWallet", "unknown"}, 200, true},
+		{"getWallet missing args", nil, []string{"getWallet"}, 400, false},
+		{"searchWallets", nil, []string{"searchWallets", ""}, 200, false},
+		{"searchWallets by mobileHash", nil, []string{"searchWallets", `"mobileHash":"` + defaultMobileHash + `"`}, 400, false},
+		{"searchWallets invalid field", nil, []string{"searchWallets", `"docType":"options"`}, 400, false},
+		{"searchWallets invalid page", nil, []string{"searchWallets", "", "first", "10"}, 400, false},
+		{"updateWalletMobileHash", nil, []string{"updateWalletMobileHash", defaultWalletID, "hash_2"}, 200, false},
+		{"updateWalletMobileHash in use", [][]string{{"createWallet", "w2", "hash_2"}}, []string{"updateWalletMobileHash", defaultWalletID, "hash_2"}, 412, false},
+		{"purgeWalletMobile", nil, []string{"purgeWalletMobile", defaultWalletID}, 200, false},
+		{"purgeWalletMobile not found", nil, []string{"purgeWalletMobile", "unknown"}, 404, false},
+		{"setMobileIndexKey missing transient", nil, []string{"setMobileIndexKey"}, 400, false},
+		{"purgeLegacyMobileHashes", nil, []string{"purgeLegacyMobileHashes"}, 200, false},
+		{"getWalletByMobileHash", nil, []string{"getWalletByMobileHash", defaultMobileHash}, 200, false},
+		{"getWalletByMobileHash not found", nil, []string{"getWalletByMobileHash", "unknown"}, 404, false},
+		{"updateWalletMobileHash not found", nil, []string{"updateWalletMobileHash", "unknown", "hash_2"}, 404, false},
+		{"updateWalletMobileHash missing args", nil, []string{"updateWalletMobileHash", defaultWalletID}, 400, false},
+		{"searchWalletTransactions", nil, []string{"searchWalletTransactions", `"walletId":"` + defaultWalletID + `"`}, 200, false},
+		{"searchWalletTransactions invalid filter", nil, []string{"searchWalletTransactions", `{"walletId":`}, 400, false},
+		{"searchTreasureTransactions", nil, []string{"searchTreasureTransactions", ""}, 200, false},
+		{"createTreasure", nil, []string{"createTreasure", "100", "other"}, 200, false},
+		{"createTreasure existing", nil, []string{"createTreasure", "100"}, 412, false},
+		{"getTreasure", nil, []string{"getTreasure"}, 200, false},
+		{"getTreasure not found", nil, []string{"getTreasure", "unknown"}, 200, true},
+		{"mintCoins", nil, []string{"mintCoins", "10", "MINT", "MINT_1"}, 200, false},
+		{"mintCoins missing args", nil, []string{"mintCoins", "10"}, 400, false},
+		{"burnCoins", nil, []string{"burnCoins", "10", "BURN", "BURN_1"}, 200, false},
+		{"burnCoins insufficient funds", nil, []string{"burnCoins", "1000000000", "BURN", "BURN_1"}, 422, false},
+		{"proposeTreasuryOp", nil, []string{"proposeTreasuryOp", "mint", "10", "MINT", "MINT_1"}, 200, false},
+		{"proposeTreasuryOp invalid operation", nil, []string{"proposeTreasuryOp", "steal", "10", "MINT", "MINT_1"}, 400, false},
+		{"approveTreasuryOp not found", nil, []string{"approveTreasuryOp", "unknown"}, 404, false},
+		{"approveTreasuryOp already approved", [][]string{{"proposeTreasuryOp", "mint", "10", "MINT", "MINT_1"}},
+			[]string{"approveTreasuryOp", "tx3"}, 412, false},
+		{"executeTreasuryOp", [][]string{{"proposeTreasuryOp", "withdraw", "10", "BONUS", "BONUS_1", defaultWalletID}},
+			[]string{"executeTreasuryOp", "tx3"}, 200, false},
+		{"executeTreasuryOp double hit", [][]string{
+			{"purchaseCoins", defaultWalletID, "10", "BONUS", "BONUS_1"},
+			{"proposeTreasuryOp", "withdraw", "10", "BONUS", "BONUS_1", defaultWalletID}},
+			[]string{"executeTreasuryOp", "tx4"}, 409, false},
+		{"executeTreasuryOp not found", nil, []string{"executeTreasuryOp", "unknown"}, 404, false},
+		{"getTreasuryOp", [][]string{{"proposeTreasuryOp", "mint", "10", "MINT", "MINT_1"}},
+			[]string{"getTreasuryOp", "tx3"}, 200, false},
+		{"getTreasuryOp not found", nil, []string{"getTreasuryOp", "unknown"}, 200, true},
+		{"purchaseCoins", nil, []string{"purchaseCoins", defaultWalletID, "10", "MAGIC_BOX", "BOX_1"}, 200, true},
+		{"purchaseCoins double hit", [][]string{{"purchaseCoins", defaultWalletID, "10", "MAGIC_BOX", "BOX_1"}},
+			[]string{"purchaseCoins", defaultWalletID, "10", "MAGIC_BOX", "BOX_1"}, 409, false},
+		{"purchaseCoins not found", nil, []string{"purchaseCoins", "unknown", "10", "MAGIC_BOX", "BOX_1"}, 404, false},
+		{"purchaseCoins missing args", nil, []string{"purchaseCoins", defaultWalletID, "10"}, 400, false},
+		{"spendCoins", nil, []string{"spendCoins", defaultWalletID, "10", "PREDICTION", "P_1"}, 200, true},
+		{"spendCoins double hit", [][]string{{"spendCoins", defaultWalletID, "10", "PREDICTION", "P_1"}},
+			[]string{"spendCoins", defaultWalletID, "10", "PREDICTION", "P_1"}, 409, false},
+		{"spendCoins insufficient funds", nil, []string{"spendCoins", defaultWalletID, "1000", "PREDICTION", "P_1"}, 402, false},
+		{"spendCoins not found", nil, []string{"spendCoins", "unknown", "10", "PREDICTION", "P_1"}, 404, false},
+		{"setOptions", nil, []string{"setOptions", "50", "partner"}, 200, false},
+		{"setOptions missing args", nil, []string{"setOptions"}, 400, false},
+		{"getOptions", nil, []string{"getOptions", "partner"}, 200, false},
+		{"setEndorsementPolicy", nil, []string{"setEndorsementPolicy", WalletObjectType, defaultWalletID, "PartnerMSP"}, 200, false},
+		{"setEndorsementPolicy not found", nil, []string{"setEndorsementPolicy", WalletObjectType, "unknown", "PartnerMSP"}, 404, false},
+		{"getEndorsementPolicy", nil, []string{"getEndorsementPolicy", TreasureObjectType}, 200, false},
+		{"getEndorsementPolicy invalid target", nil, []string{"getEndorsementPolicy", "unknown"}, 400, false},
+		{"getMigrationPage", nil, []string{"getMigrationPage", WalletObjectType}, 200, false},
+		{"getMigrationPage invalid pageSize", nil, []string{"getMigrationPage", WalletObjectType, "", "0"}, 400, false},
+		{"migrate", nil, []string{"migrate", WalletObjectType}, 200, false},
+		{"migrate invalid docType", nil, []string{"migrate", "unknown"}, 400, false},
+		{"describe", nil, []string{"describe"}, 200, false},
+		{"pauseContract", nil, []string{"pauseContract", "", "", "incident"}, 200, false},
+		{"pauseContract invalid function", nil, []string{"pauseContract", "", "unknown"}, 400, false},
+		{"pauseContract query", nil, []string{"pauseContract", "", "getWallet"}, 400, false},
+		{"unpauseContract", [][]string{{"pauseContract", DefaultCustomer}}, []string{"unpauseContract", DefaultCustomer}, 200, true},
+		{"unpauseContract not paused", nil, []string{"unpauseContract"}, 404, false},
+		{"getPauses", [][]string{{"pauseContract"}}, []string{"getPauses"}, 200, false},
+		{"rotateWalletKey invalid key", nil, []string{"rotateWalletKey", defaultWalletID, "a2V5"}, 400, false},
+		{"rotateWalletKey not found", nil, []string{"rotateWalletKey", "unknown", testWalletKey}, 404, false},
+		{"rotateWalletKey missing args", nil, []string{"rotateWalletKey", defaultWalletID}, 400, false},
+		{"getWalletNonce", nil, []string{"getWalletNonce", defaultWalletID}, 200, false},
+		{"getWalletNonce not found", nil, []string{"getWalletNonce", "unknown"}, 404, false},
+		{"getWalletNonce missing args", nil, []string{"getWalletNonce"}, 400, false},
+		{"executeSigned unsigned wallet", nil, []string{"executeSigned", `{"function":"spendCoins","args":["` + defaultWalletID + `","10","order","1"]}`}, 403, false},
+		{"executeSigned unknown function", nil, []string{"executeSigned", `{"function":"purchaseCoins","args":[]}`}, 400, false},
+		{"executeSigned invalid operation", nil, []string{"executeSigned", `{"function":"spendCoins","args":"w1"}`}, 400, false},
+		{"executeSigned missing args", nil, []string{"executeSigned"}, 400, false},
+		{"recoverWallet", nil, []string{"recoverWallet", defaultWalletID, "w2", RecoveryLostAccess}, 200, false},
+		{"recoverWallet invalid reason", nil, []string{"recoverWallet", defaultWalletID, "w2", "lost"}, 400, false},
+		{"recoverWallet to itself", nil, []string{"recoverWallet", defaultWalletID, defaultWalletID, RecoveryLostAccess}, 400, false},
+		{"recoverWallet not found", nil, []string{"recoverWallet", "unknown", "w2", RecoveryLostAccess}, 404, false},
+		{"recoverWallet closed", [][]string{{"recoverWallet", defaultWalletID, "w2", RecoveryLostAccess}},
+			[]string{"recoverWallet", defaultWalletID, "w3", RecoveryLostAccess}, 403, false},
+		{"recoverWallet missing args", nil, []string{"recoverWallet", defaultWalletID, "w2"}, 400, false},
+		{"spendCoins paused", [][]string{{"pauseContract"}}, []string{"spendCoins", defaultWalletID, "10", "order", "1"}, 503, false},
+		{"unknown function", nil, []string{"unknown"}, 400, false},
+	}
+
+	for _, test := range tests {
+		f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, DefaultRegistrationAmount).build()
+		for _, setup := range test.setup {
+			response := f.invoke(setup[0], setup[1:]...)
+			assert(t, response.GetStatus() == 200, "%s: setup %v failed: %s", test.name, setup, response.GetMessage())
+		}
+
+		response := f.invoke(test.args[0], test.args[1:]...)
+		assert(t, response.GetStatus() == test.status, "%s: expected status %d, got %d: %s",
+			test.name, test.status, response.GetStatus(), response.GetMessage())
+		if test.status == 200 {
+			assert(t, (len(response.GetPayload()) == 0) == test.empty, "%s: unexpected payload %q", test.name, response.GetPayload())
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// Package chaincode implements the ninjastack smart contract. The chaincode
+// binary in the repository root and the tools in cmd run it.
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+var logger = shim.NewLogger("ninjastackSmartContract")
+
+// SetLogLevel sets the level of the chaincode logger.
+func SetLogLevel(level shim.LoggingLevel) {
+	logger.SetLevel(level)
+}
@@ -0,0 +1,358 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/msp"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestMain(m *testing.M) {
+	logger.SetLevel(shim.LogError)
+	os.Setenv("MODE", "TEST")
+	fmt.Printf("\n\n*--------------* RUN Mode set to %s *-----------------* \n \n", os.Getenv("MODE"))
+	os.Exit(m.Run())
+}
+
+// assert fails the test if the condition is false.
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	if !condition {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("\033[31m%s:%d: "+msg+"\033[39m\n\n", append([]interface{}{filepath.Base(file), line}, v...)...)
+		tb.FailNow()
+	}
+}
+
+// ok fails the test if an err is not nil.
+func ok(tb testing.TB, err error) {
+	if err != nil {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("\033[31m%s:%d: unexpected error: %s\033[39m\n\n", filepath.Base(file), line, err.Error())
+		tb.FailNow()
+	}
+}
+
+// equals fails the test if exp is not equal to act.
+func equals(tb testing.TB, exp, act interface{}) {
+	if !reflect.DeepEqual(exp, act) {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("\033[31m%s:%d:\n\n\texp: %#v\n\n\tgot: %#v\033[39m\n\n", filepath.Base(file), line, exp, act)
+		tb.FailNow()
+	}
+}
+
+// invokerStub runs transactions against a MockStub on behalf of a fixed
+// client identity, which the MockStub itself cannot carry.
+type invokerStub struct {
+	*shim.MockStub
+	cc      shim.Chaincode
+	args    [][]byte
+	creator []byte
+	// queryErr is returned by rich queries instead of their results
+	queryErr error
+	// event is the chaincode event of the last transaction
+	event *sc.ChaincodeEvent
+	// transient is the transient map of the proposal
+	transient map[string][]byte
+}
+
+// newInvoker returns an invoker with a self-signed certificate for name in mspID.
+func newInvoker(tb testing.TB, mockStub *shim.MockStub, mspID, name string) *invokerStub {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ok(tb, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name, Organization: []string{mspID}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	ok(tb, err)
+
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	ok(tb, err)
+
+	return &invokerStub{MockStub: mockStub, cc: new(SmartContract), creator: creator}
+}
+
+func (s *invokerStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+func (s *invokerStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *invokerStub) GetStringArgs() []string {
+	args := make([]string, 0, len(s.args))
+	for _, arg := range s.args {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+func (s *invokerStub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+// GetTransient returns the transient map of the proposal, which the MockStub
+// does not carry.
+func (s *invokerStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+// DelPrivateData deletes from the private data of the MockStub, which does
+// not implement it.
+func (s *invokerStub) DelPrivateData(collection, key string) error {
+	delete(s.PvtState[collection], key)
+	return nil
+}
+
+// SetEvent keeps the event of the transaction, the last one set like on a
+// peer. The MockStub queues events on a channel that nothing drains.
+func (s *invokerStub) SetEvent(name string, payload []byte) error {
+	s.event = &sc.ChaincodeEvent{EventName: name, Payload: payload}
+	return nil
+}
+
+func (s *invokerStub) MockInit(uuid string, args [][]byte) sc.Response {
+	s.args = args
+	s.MockTransactionStart(uuid)
+	response := s.cc.Init(s)
+	s.MockTransactionEnd(uuid)
+	return response
+}
+
+// MockInvoke discards the writes of a failed transaction, as a peer would
+// by not committing it. The MockStub alone keeps them.
+func (s *invokerStub) MockInvoke(uuid string, args [][]byte) sc.Response {
+	s.args = args
+	s.event = nil
+	snapshot := s.snapshot()
+	s.MockTransactionStart(uuid)
+	response := s.cc.Invoke(s)
+	s.MockTransactionEnd(uuid)
+	if response.GetStatus() >= shim.ERRORTHRESHOLD {
+		s.restore(snapshot)
+		s.event = nil
+	}
+	return response
+}
+
+// ledgerSnapshot is a copy of the state of a MockStub.
+type ledgerSnapshot struct {
+	state    map[string][]byte
+	keys     []string
+	pvtState map[string]map[string][]byte
+	policies map[string]map[string][]byte
+}
+
+func (s *invokerStub) snapshot() *ledgerSnapshot {
+	snapshot := &ledgerSnapshot{
+		state:    copyState(s.State),
+		pvtState: copyCollections(s.PvtState),
+		policies: copyCollections(s.EndorsementPolicies),
+	}
+	for elem := s.Keys.Front(); elem != nil; elem = elem.Next() {
+		snapshot.keys = append(snapshot.keys, elem.Value.(string))
+	}
+	return snapshot
+}
+
+func (s *invokerStub) restore(snapshot *ledgerSnapshot) {
+	s.State = snapshot.state
+	s.PvtState = snapshot.pvtState
+	s.EndorsementPolicies = snapshot.policies
+	s.Keys.Init()
+	for _, key := range snapshot.keys {
+		s.Keys.PushBack(key)
+	}
+}
+
+func copyState(state map[string][]byte) map[string][]byte {
+	copied := make(map[string][]byte, len(state))
+	for key, value := range state {
+		copied[key] = value
+	}
+	return copied
+}
+
+// copyCollections copies state kept per collection, such as the private data
+// and the endorsement policies of a MockStub.
+func copyCollections(collections map[string]map[string][]byte) map[string]map[string][]byte {
+	copied := make(map[string]map[string][]byte, len(collections))
+	for collection, state := range collections {
+		copied[collection] = copyState(state)
+	}
+	return copied
+}
+
+// fixtureBuilder describes the ledger state a test starts from. Every build
+// creates a fresh MockStub, so tests never share state.
+type fixtureBuilder struct {
+	t       testing.TB
+	genesis GenesisConfig
+	wallets []Wallet
+}
+
+// fixture is an initialized chaincode invoked by a default Org1MSP identity.
+type fixture struct {
+	*invokerStub
+	t       testing.TB
+	txCount int
+}
+
+// newFixture starts from the default genesis: the default treasury amount
+// and the default registration for the default customer.
+func newFixture(t testing.TB) *fixtureBuilder {
+	treasury, err := strconv.ParseFloat(DefaultTreasureAmount, 64)
+	ok(t, err)
+
+	return &fixtureBuilder{t: t, genesis: GenesisConfig{Treasury: treasury}}
+}
+
+func (b *fixtureBuilder) withTreasury(amount float64) *fixtureBuilder {
+	b.genesis.Treasury = amount
+	return b
+}
+
+func (b *fixtureBuilder) withOptions(options Options) *fixtureBuilder {
+	b.genesis.Options = append(b.genesis.Options, options)
+	return b
+}
+
+func (b *fixtureBuilder) withAdmins(mspIDs ...string) *fixtureBuilder {
+	b.genesis.AdminMSPs = mspIDs
+	return b
+}
+
+// withWallet registers a wallet through createWallet, so its amount is taken
+// from the treasury like in production.
+func (b *fixtureBuilder) withWallet(id, mobileHash string, amount float64) *fixtureBuilder {
+	b.wallets = append(b.wallets, Wallet{ID: id, MobileHash: mobileHash, Amount: amount})
+	return b
+}
+
+func (b *fixtureBuilder) build() *fixture {
+	mockStub := shim.NewMockStub("fixture", new(SmartContract))
+	f := &fixture{invokerStub: newInvoker(b.t, mockStub, "Org1MSP", "user1"), t: b.t}
+
+	config, err := json.Marshal(b.genesis)
+	ok(b.t, err)
+
+	response := f.MockInit(f.nextTxID(), [][]byte{[]byte("init"), config})
+	equals(b.t, int32(200), response.GetStatus())
+
+	for _, wallet := range b.wallets {
+		response = f.invoke("createWallet", wallet.ID, wallet.MobileHash,
+			strconv.FormatFloat(wallet.Amount, 'f', -1, 64), "registration", wallet.ID)
+		equals(b.t, int32(200), response.GetStatus())
+	}
+	return f
+}
+
+func (f *fixture) nextTxID() string {
+	f.txCount++
+	return "tx" + strconv.Itoa(f.txCount)
+}
+
+// invoke runs function in a transaction with a new id.
+func (f *fixture) invoke(function string, args ...string) sc.Response {
+	return f.invokeAs(f.invokerStub, function, args...)
+}
+
+// invokeWithTransient runs function with a transient map in the proposal.
+func (f *fixture) invokeWithTransient(transient map[string][]byte, function string, args ...string) sc.Response {
+	f.transient = transient
+	defer func() { f.transient = nil }()
+	return f.invoke(function, args...)
+}
+
+// invokeAs runs function on behalf of invoker, see as.
+func (f *fixture) invokeAs(invoker *invokerStub, function string, args ...string) sc.Response {
+	byteArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		byteArgs = append(byteArgs, []byte(arg))
+	}
+	return invoker.MockInvoke(f.nextTxID(), byteArgs)
+}
+
+// as returns another identity on the ledger of the fixture.
+func (f *fixture) as(mspID, name string) *invokerStub {
+	return newInvoker(f.t, f.MockStub, mspID, name)
+}
+
+func (f *fixture) wallet(id string) *Wallet {
+	response := f.invoke("getWallet", id)
+	equals(f.t, int32(200), response.GetStatus())
+
+	var wallet = new(Wallet)
+	err := json.Unmarshal(response.GetPayload(), wallet)
+	ok(f.t, err)
+	return wallet
+}
+
+func (f *fixture) walletByMobileHash(mobileHash string) *Wallet {
+	response := f.invoke("getWalletByMobileHash", mobileHash)
+	equals(f.t, int32(200), response.GetStatus())
+
+	var wallet = new(Wallet)
+	err := json.Unmarshal(response.GetPayload(), wallet)
+	ok(f.t, err)
+	return wallet
+}
+
+func (f *fixture) walletNonce(id string) *WalletNonce {
+	response := f.invoke("getWalletNonce", id)
+	equals(f.t, int32(200), response.GetStatus())
+
+	var walletNonce = new(WalletNonce)
+	err := json.Unmarshal(response.GetPayload(), walletNonce)
+	ok(f.t, err)
+	return walletNonce
+}
+
+func (f *fixture) treasure() *Treasure {
+	response := f.invoke("getTreasure")
+	equals(f.t, int32(200), response.GetStatus())
+
+	var treasure = new(Treasure)
+	err := json.Unmarshal(response.GetPayload(), treasure)
+	ok(f.t, err)
+	return treasure
+}
+
+func (f *fixture) options(customer string) *Options {
+	response := f.invoke("getOptions", customer)
+	equals(f.t, int32(200), response.GetStatus())
+
+	var options = new(Options)
+	err := json.Unmarshal(response.GetPayload(), options)
+	ok(f.t, err)
+	return options
+}
@@ -0,0 +1,296 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// middleware wraps the handler of a function with behavior that every
+// function shares.
+type middleware func(fn *Function, next handlerFunc) handlerFunc
+
+// pipeline is applied to every registered function, outermost first. The
+// recovery comes after metrics and logging so that panics are counted and
+// logged as failed calls.
+var pipeline = []middleware{
+	withMetrics,
+	withLogging,
+	withRecovery,
+	withAuthorization,
+	withValidation,
+	withPause,
+	withReadOnly,
+	withEvent,
+}
+
+func applyPipeline(fn *Function) handlerFunc {
+	call := fn.handler
+	for i := len(pipeline) - 1; i >= 0; i-- {
+		call = pipeline[i](fn, call)
+	}
+	return call
+}
+
+// withRecovery turns a panic of the handler into an INTERNAL error, so that a
+// bug fails the transaction instead of the chaincode container.
+func withRecovery(fn *Function, next handlerFunc) handlerFunc {
+	return func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) (response sc.Response) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error(fmt.Sprintf("%s panicked: %v\n%s", fn.Name, r, debug.Stack()))
+				response = errorResponse(newError(CodeInternal, "Internal error in "+fn.Name))
+			}
+		}()
+		return next(s, stub, args)
+	}
+}
+
+// FunctionMetrics counts the calls of a function on this peer since the
+// chaincode started.
+type FunctionMetrics struct {
+	Calls    int64               `json:"calls"`
+	Errors   map[ErrorCode]int64 `json:"errors,omitempty"`
+	Duration time.Duration       `json:"duration"`
+}
+
+var metrics = struct {
+	sync.Mutex
+	functions map[string]*FunctionMetrics
+}{functions: map[string]*FunctionMetrics{}}
+
+// Metrics returns the metrics of every function that has been called.
+// They are kept in memory per chaincode container and are never written to
+// the ledger, since they differ between peers.
+func Metrics() map[string]FunctionMetrics {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	result := map[string]FunctionMetrics{}
+	for name, m := range metrics.functions {
+		errorCounts := map[ErrorCode]int64{}
+		for code, count := range m.Errors {
+			errorCounts[code] = count
+		}
+		result[name] = FunctionMetrics{Calls: m.Calls, Errors: errorCounts, Duration: m.Duration}
+	}
+	return result
+}
+
+func withMetrics(fn *Function, next handlerFunc) handlerFunc {
+	return func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) sc.Response {
+		start := time.Now()
+		response := next(s, stub, args)
+		elapsed := time.Since(start)
+
+		metrics.Lock()
+		defer metrics.Unlock()
+		m, found := metrics.functions[fn.Name]
+		if !found {
+			m = &FunctionMetrics{Errors: map[ErrorCode]int64{}}
+			metrics.functions[fn.Name] = m
+		}
+		m.Calls++
+		m.Duration += elapsed
+		if response.GetStatus() >= shim.ERRORTHRESHOLD {
+			code, _ := ErrorCodeOf(response.GetStatus())
+			m.Errors[code]++
+		}
+		return response
+	}
+}
+
+// withLogging logs every call with its outcome. Sensitive arguments are
+// redacted.
+func withLogging(fn *Function, next handlerFunc) handlerFunc {
+	return func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) sc.Response {
+		logger.Info(fmt.Sprintf("Starting ninjastackcoin smart contract Invoke for %s and arguments passed are %v", fn.Name, redactArgs(fn.Args, args)))
+
+		response := next(s, stub, args)
+		if response.GetStatus() >= shim.ERRORTHRESHOLD {
+			logger.Warning(fmt.Sprintf("%s failed with status %d: %s", fn.Name, response.GetStatus(), response.GetMessage()))
+		}
+		return response
+	}
+}
+
+const redacted = "[redacted]"
+
+// redactArgs returns the arguments with the values of sensitive fields
+// replaced, for positional arguments as well as a JSON object argument.
+func redactArgs(schema []ArgField, args []string) []string {
+
+	fields, isObject := objectArg(schema, args)
+	if isObject {
+		if fields == nil {
+			return args
+		}
+		for _, field := range schema {
+			if _, found := fields[field.Name]; found && field.Sensitive {
+				fields[field.Name], _ = json.Marshal(redacted)
+			}
+		}
+		object, _ := json.Marshal(fields)
+		return []string{string(object)}
+	}
+
+	result := append([]string(nil), args...)
+	for i, field := range schema {
+		if field.Type == ArgVariadic {
+			break
+		}
+		if i < len(result) && field.Sensitive {
+			result[i] = redacted
+		}
+	}
+	return result
+}
+
+func withAuthorization(fn *Function, next handlerFunc) handlerFunc {
+	if fn.Role != RoleAdmin {
+		return next
+	}
+	return func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) sc.Response {
+		err := s.checkAdmin(stub)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return next(s, stub, args)
+	}
+}
+
+// withValidation converts a JSON object argument to positional arguments and
+// checks them against the schema of the function.
+func withValidation(fn *Function, next handlerFunc) handlerFunc {
+	return func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) sc.Response {
+		args, err := parseArgs(fn.Args, args)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return next(s, stub, args)
+	}
+}
+
+// withPause refuses the call while a pause covers the function. The call is
+// for its customer argument and for the customer of the wallet it changes,
+// so that a different customer argument does not get past a pause of the
+// wallet's customer. Functions without either are only stopped by a pause of
+// the whole contract.
+func withPause(fn *Function, next handlerFunc) handlerFunc {
+	if !pausable(fn) {
+		return next
+	}
+
+	customerField, walletField := -1, -1
+	for i, field := range fn.Args {
+		switch field.Name {
+		case "customer":
+			customerField = i
+		case "walletId":
+			walletField = i
+		}
+	}
+
+	return func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) sc.Response {
+		var customers []string
+		if customerField >= 0 {
+			customer := fn.Args[customerField].Default
+			if customerField < len(args) && args[customerField] != "" {
+				customer = args[customerField]
+			}
+			customers = append(customers, customer)
+		}
+
+		if walletField >= 0 && walletField < len(args) && args[walletField] != "" {
+			customer, err := walletCustomer(stub, args[walletField])
+			if err != nil {
+				return errorResponse(err)
+			}
+			customers = append(customers, customer)
+		}
+
+		err := checkPaused(stub, fn.Name, customers...)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return next(s, stub, args)
+	}
+}
+
+var errReadOnly = newError(CodeInternal, "Read-only function attempted to write to the ledger")
+
+// readOnlyStub rejects every write of a read-only function.
+type readOnlyStub struct {
+	shim.ChaincodeStubInterface
+}
+
+func (stub readOnlyStub) PutState(key string, value []byte) error {
+	return errReadOnly
+}
+
+func (stub readOnlyStub) DelState(key string) error {
+	return errReadOnly
+}
+
+func (stub readOnlyStub) SetStateValidationParameter(key string, ep []byte) error {
+	return errReadOnly
+}
+
+func (stub readOnlyStub) PutPrivateData(collection, key string, value []byte) error {
+	return errReadOnly
+}
+
+func (stub readOnlyStub) DelPrivateData(collection, key string) error {
+	return errReadOnly
+}
+
+func (stub readOnlyStub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return errReadOnly
+}
+
+func withReadOnly(fn *Function, next handlerFunc) handlerFunc {
+	if !fn.ReadOnly {
+		return next
+	}
+	return func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) sc.Response {
+		return next(s, readOnlyStub{stub}, args)
+	}
+}
+
+// Event is the payload of the chaincode event of a transaction that wrote to
+// the ledger. The event is named after the function, and sensitive arguments
+// are redacted like in the logs.
+type Event struct {
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+}
+
+// withEvent sets the chaincode event of a successful call that writes.
+// Fabric keeps one event per transaction, so handlers set none of their own.
+func withEvent(fn *Function, next handlerFunc) handlerFunc {
+	if fn.ReadOnly {
+		return next
+	}
+	return func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) sc.Response {
+		response := next(s, stub, args)
+		if response.GetStatus() >= shim.ERRORTHRESHOLD {
+			return response
+		}
+
+		asBytes, err := json.Marshal(Event{Function: fn.Name, Args: redactArgs(fn.Args, args)})
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = stub.SetEvent(fn.Name, asBytes)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return response
+	}
+}
@@ -0,0 +1,98 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestRedactArgs(t *testing.T) {
+	t.Log("Test sensitive arguments are redacted from the logs")
+	schema := registry["createWallet"].Args
+
+	equals(t, []string{"w1", redacted, "10"}, redactArgs(schema, []string{"w1", "hash1", "10"}))
+	equals(t, []string{"w1"}, redactArgs(schema, []string{"w1"}))
+	equals(t, []string{`{"mobileHash":"` + redacted + `","walletId":"w1"}`},
+		redactArgs(schema, []string{`{"walletId":"w1","mobileHash":"hash1"}`}))
+	equals(t, []string{`{"walletId":`}, redactArgs(schema, []string{`{"walletId":`}))
+	equals(t, []string{"w1", "5"}, redactArgs(registry["spendCoins"].Args, []string{"w1", "5"}))
+}
+
+func TestEvent(t *testing.T) {
+	t.Log("Test a transaction that writes sets an event named after its function")
+	f := newFixture(t).withWallet("w1", "hash1", 10).build()
+
+	response := f.invoke("createWallet", "w2", "hash2", "5", "registration", "w2")
+	equals(t, int32(200), response.GetStatus())
+	equals(t, "createWallet", f.event.GetEventName())
+
+	var event Event
+	err := json.Unmarshal(f.event.GetPayload(), &event)
+	ok(t, err)
+	equals(t, Event{Function: "createWallet", Args: []string{"w2", redacted, "5", "registration", "w2"}}, event)
+
+	// ---- Negative Cases ----
+	response = f.invoke("getWallet", "w2")
+	equals(t, int32(200), response.GetStatus())
+	assert(t, f.event == nil, "query set event %v", f.event)
+
+	response = f.invoke("spendCoins", "w1", "100", "order", "1")
+	equals(t, CodeInsufficientFunds.Status(), response.GetStatus())
+	assert(t, f.event == nil, "failed transaction set event %v", f.event)
+}
+
+func TestPipeline(t *testing.T) {
+	t.Log("Test the middleware wraps every call")
+	f := newFixture(t).withAdmins("AdminMSP").build()
+
+	var handlerArgs []string
+	fn := &Function{
+		Name: "testPipeline",
+		Args: []ArgField{{Name: "key", Type: ArgString, Required: true}, {Name: "amount", Type: ArgNumber}},
+		Role: RoleAdmin,
+		handler: func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) sc.Response {
+			handlerArgs = args
+			if args[0] == "panic" {
+				panic("handler bug")
+			}
+			err := stub.PutState(args[0], []byte("value"))
+			if err != nil {
+				return errorResponse(err)
+			}
+			return shim.Success(nil)
+		},
+	}
+	call := applyPipeline(fn)
+	admin := f.as("AdminMSP", "admin")
+	admin.MockTransactionStart("tx-pipeline")
+	defer admin.MockTransactionEnd("tx-pipeline")
+
+	response := call(new(SmartContract), admin, []string{`{"key":"k1","amount":1.5}`})
+	equals(t, int32(200), response.GetStatus())
+	equals(t, []string{"k1", "1.5"}, handlerArgs)
+
+	// ---- Negative Cases ----
+	handlerArgs = nil
+	response = call(new(SmartContract), f.as("Org1MSP", "user"), []string{"k2"})
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+	assert(t, handlerArgs == nil, "handler called for a forbidden invoker")
+
+	response = call(new(SmartContract), admin, []string{"k2", "many"})
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+	assert(t, handlerArgs == nil, "handler called with invalid arguments")
+
+	response = call(new(SmartContract), admin, []string{"panic"})
+	equals(t, CodeInternal.Status(), response.GetStatus())
+	equals(t, "Internal error in testPipeline", response.GetMessage())
+
+	fn.ReadOnly = true
+	response = applyPipeline(fn)(new(SmartContract), admin, []string{"k3"})
+	equals(t, CodeInternal.Status(), response.GetStatus())
+	equals(t, errReadOnly.Message, response.GetMessage())
+
+	m := Metrics()["testPipeline"]
+	equals(t, int64(5), m.Calls)
+	equals(t, map[ErrorCode]int64{CodeForbidden: 1, CodeInvalidArgument: 1, CodeInternal: 2}, m.Errors)
+}
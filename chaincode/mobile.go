@@ -0,0 +1,482 @@
+package chaincode
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Mobile identifiers are kept in the private data collection MobileCollection,
+// see collections_config.json. They are passed in the transient map, which is
+// neither logged nor written to the blocks, instead of as arguments:
+//
+//	mobile    the mobile identifier of the wallet
+//	salt      at least MinMobileSaltLength random bytes chosen by the client
+//
+// The public wallet document only holds the commitment sha256(salt | mobile)
+// as its mobileHash. A mobile identifier belongs to at most one wallet; the
+// private index that enforces it is keyed by an HMAC of the identifier with
+// the index key of the collection, because the hashes of private keys are
+// public.
+//
+// Wallets registered before the collection carry a mobile hash computed by
+// the client. The mobileHash argument is rejected once setMobileIndexKey has
+// run, and purgeLegacyMobileHashes removes the hashes that are left.
+
+const (
+	MobileCollection       = "mobileCollection"
+	WalletMobileObjectType = "walletMobile"
+	MobileIndex            = "mobile~walletId"
+	MobileIndexKeyID       = "MobileIndexKey"
+
+	MobileUpdateAction = "MOBILE_UPDATE"
+	MobilePurgeAction  = "MOBILE_PURGE"
+
+	TransientMobile   = "mobile"
+	TransientSalt     = "salt"
+	TransientIndexKey = "indexKey"
+
+	MinMobileSaltLength     = 16
+	MinMobileIndexKeyLength = 32
+)
+
+// WalletMobile is the private document of the mobile identifier of a wallet.
+type WalletMobile struct {
+	ObjectType    string `json:"docType"`
+	SchemaVersion int    `json:"schemaVersion"`
+	WalletID      string `json:"walletId"`
+	Mobile        string `json:"mobile"`
+	Salt          []byte `json:"salt"`
+}
+
+var (
+	errMissingMobile         = newError(CodeInvalidArgument, "Missing argument mobileHash. Expecting a mobileHash or a transient mobile")
+	errMobileInUse           = newError(CodeAlreadyExists, "Mobile is already used by another wallet")
+	errMobileIndexKeyMissing = newError(CodeInternal, "Mobile index key is not set, see setMobileIndexKey")
+	errMobileHashArgument    = newError(CodeInvalidArgument, "Argument mobileHash is no longer accepted. Expecting a transient mobile")
+)
+
+// getTransientMobile returns the mobile identifier and salt of the transient
+// map; the salt is checked when the mobile identifier is stored. found is
+// false when the transaction passes no mobile identifier.
+func getTransientMobile(stub shim.ChaincodeStubInterface) (mobile string, salt []byte, found bool, err error) {
+
+	transient, err := stub.GetTransient()
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	mobileBytes, found := transient[TransientMobile]
+	if !found {
+		return "", nil, false, nil
+	}
+
+	if len(mobileBytes) == 0 {
+		return "", nil, false, newError(CodeInvalidArgument, "Invalid transient mobile: must not be empty")
+	}
+
+	return string(mobileBytes), transient[TransientSalt], true, nil
+}
+
+// checkMobileHashArgument rejects the deprecated mobileHash argument once
+// the mobile index key is set. A mobile hash computed by the client is
+// written to the world state as is and can be reversed by hashing every
+// mobile number, so it is only accepted from clients that predate the
+// private collection, until the network has set it up.
+func checkMobileHashArgument(stub shim.ChaincodeStubInterface, mobileHash string) error {
+
+	if mobileHash == "" {
+		return nil
+	}
+
+	indexKey, err := stub.GetPrivateData(MobileCollection, MobileIndexKeyID)
+	if err != nil {
+		return err
+	}
+
+	if len(indexKey) != 0 {
+		return errMobileHashArgument
+	}
+
+	logger.Warning("Argument mobileHash is deprecated, pass the mobile in the transient map")
+	return nil
+}
+
+func mobileCommitment(mobile string, salt []byte) string {
+	hash := sha256.New()
+	hash.Write(salt)
+	hash.Write([]byte(mobile))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// mobileIndexEntry returns the key of the private index entry of a mobile
+// identifier.
+func mobileIndexEntry(stub shim.ChaincodeStubInterface, mobile string) (string, error) {
+
+	indexKey, err := stub.GetPrivateData(MobileCollection, MobileIndexKeyID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(indexKey) == 0 {
+		return "", errMobileIndexKeyMissing
+	}
+
+	mac := hmac.New(sha256.New, indexKey)
+	mac.Write([]byte(mobile))
+	return stub.CreateCompositeKey(MobileIndex, []string{hex.EncodeToString(mac.Sum(nil))})
+}
+
+// putWalletMobile stores the mobile identifier of a wallet in the private
+// collection, replacing its previous one, and returns the commitment for the
+// public wallet document.
+func putWalletMobile(stub shim.ChaincodeStubInterface, walletID, mobile string, salt []byte) (string, error) {
+
+	if len(salt) < MinMobileSaltLength {
+		return "", newError(CodeInvalidArgument, "Invalid transient salt: expecting at least "+strconv.Itoa(MinMobileSaltLength)+" bytes")
+	}
+
+	entry, err := mobileIndexEntry(stub, mobile)
+	if err != nil {
+		return "", err
+	}
+
+	owner, err := stub.GetPrivateData(MobileCollection, entry)
+	if err != nil {
+		return "", err
+	}
+
+	if len(owner) != 0 && string(owner) != walletID {
+		return "", errMobileInUse
+	}
+
+	err = delWalletMobile(stub, walletID)
+	if err != nil {
+		return "", err
+	}
+
+	err = stub.PutPrivateData(MobileCollection, entry, []byte(walletID))
+	if err != nil {
+		return "", err
+	}
+
+	key, err := stub.CreateCompositeKey(WalletMobileObjectType, []string{walletID})
+	if err != nil {
+		return "", err
+	}
+
+	var walletMobile = new(WalletMobile)
+	walletMobile.ObjectType = WalletMobileObjectType
+	walletMobile.SchemaVersion = schemaVersion(WalletMobileObjectType)
+	walletMobile.WalletID = walletID
+	walletMobile.Mobile = mobile
+	walletMobile.Salt = salt
+
+	asBytes, err := json.Marshal(walletMobile)
+	if err != nil {
+		return "", err
+	}
+
+	err = stub.PutPrivateData(MobileCollection, key, asBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return mobileCommitment(mobile, salt), nil
+}
+
+// delWalletMobile deletes the private document of a wallet and its index
+// entry, if the wallet has one.
+func delWalletMobile(stub shim.ChaincodeStubInterface, walletID string) error {
+
+	key, err := stub.CreateCompositeKey(WalletMobileObjectType, []string{walletID})
+	if err != nil {
+		return err
+	}
+
+	asBytes, err := stub.GetPrivateData(MobileCollection, key)
+	if err != nil {
+		return err
+	}
+
+	if len(asBytes) == 0 {
+		return nil
+	}
+
+	var walletMobile = new(WalletMobile)
+	err = unmarshalDocument(stub, asBytes, walletMobile)
+	if err != nil {
+		return err
+	}
+
+	entry, err := mobileIndexEntry(stub, walletMobile.Mobile)
+	if err != nil {
+		return err
+	}
+
+	owner, err := stub.GetPrivateData(MobileCollection, entry)
+	if err != nil {
+		return err
+	}
+
+	if string(owner) == walletID {
+		err = stub.DelPrivateData(MobileCollection, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return stub.DelPrivateData(MobileCollection, key)
+}
+
+// getWalletIDByMobile returns the wallet of a mobile identifier, or an empty
+// id when no wallet has it.
+func getWalletIDByMobile(stub shim.ChaincodeStubInterface, mobile string) (string, error) {
+
+	entry, err := mobileIndexEntry(stub, mobile)
+	if err != nil {
+		return "", err
+	}
+
+	walletID, err := stub.GetPrivateData(MobileCollection, entry)
+	return string(walletID), err
+}
+
+// setMobileIndexKey sets the secret key of the private mobile index from the
+// transient indexKey. It can only be set once, since the index entries of
+// the stored wallets are derived from it.
+func (s *SmartContract) setMobileIndexKey(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	transient, err := stub.GetTransient()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	indexKey := transient[TransientIndexKey]
+	if len(indexKey) < MinMobileIndexKeyLength {
+		return errorResponse(newError(CodeInvalidArgument, "Invalid transient indexKey: expecting at least "+strconv.Itoa(MinMobileIndexKeyLength)+" bytes"))
+	}
+
+	asBytes, err := stub.GetPrivateData(MobileCollection, MobileIndexKeyID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if len(asBytes) != 0 {
+		return errorResponse(newError(CodeAlreadyExists, "Mobile index key is already set"))
+	}
+
+	err = stub.PutPrivateData(MobileCollection, MobileIndexKeyID, indexKey)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(nil)
+}
+
+// purgeWalletMobile erases the mobile identifier of a wallet, for erasure
+// requests: the private document and its index entry are deleted, the
+// mobile hash is removed from the public wallet document and from the
+// MOBILE_UPDATE transactions of the wallet. Peers keep the deleted private
+// data in their private block store until the blockToLive of the
+// collection, and the blocks keep the public writes.
+func (s *SmartContract) purgeWalletMobile(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	walletID := args[0]
+	key, err := stub.CreateCompositeKey(WalletObjectType, []string{walletID})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	walletAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if len(walletAsBytes) == 0 {
+		return errorResponse(newError(CodeNotFound, "Wallet with id "+walletID+" not found"))
+	}
+
+	var wallet = new(Wallet)
+	err = unmarshalDocument(stub, walletAsBytes, wallet)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	walletAsBytes, err = s.purgeMobile(stub, key, wallet)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(walletAsBytes)
+}
+
+// purgeLegacyMobileHashes purges the mobile hashes that wallets were
+// registered with through the deprecated mobileHash argument, for the keys
+// of a MigrationPage of wallets. Wallets whose mobile is in the private
+// collection keep their commitment. The wallets lose their mobile, so run it
+// once the clients have registered the mobiles of the wallets they still
+// use with updateWalletMobileHash. Migrated counts the purged wallets.
+func (s *SmartContract) purgeLegacyMobileHashes(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	var progress = new(MigrationProgress)
+	progress.DocType = WalletObjectType
+	for _, encoded := range args {
+		key, err := migrationKey(stub, WalletObjectType, encoded)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		walletAsBytes, err := stub.GetState(key)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if len(walletAsBytes) == 0 {
+			continue
+		}
+		progress.Scanned++
+
+		var wallet = new(Wallet)
+		err = unmarshalDocument(stub, walletAsBytes, wallet)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if wallet.MobileHash == "" {
+			continue
+		}
+
+		mobileKey, err := stub.CreateCompositeKey(WalletMobileObjectType, []string{wallet.ID})
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		walletMobile, err := stub.GetPrivateData(MobileCollection, mobileKey)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if len(walletMobile) != 0 {
+			continue
+		}
+
+		_, err = s.purgeMobile(stub, key, wallet)
+		if err != nil {
+			return errorResponse(err)
+		}
+		progress.Migrated++
+	}
+
+	asBytes, err := json.Marshal(progress)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+// purgeMobile erases the mobile of the wallet stored at key and returns the
+// stored wallet.
+func (s *SmartContract) purgeMobile(stub shim.ChaincodeStubInterface, key string, wallet *Wallet) ([]byte, error) {
+
+	err := delWalletMobile(stub, wallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putMobileHashIndex(stub, wallet.MobileHash, &Wallet{ID: wallet.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	wallet.MobileHash = ""
+
+	err = redactMobileUpdates(stub, wallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.createWalletTransaction(stub, 0, wallet.ID, "mobile purge", stub.GetTxID(), MobilePurgeAction, stub.GetTxID(), DefaultCustomer)
+	if err != nil {
+		return nil, err
+	}
+
+	walletAsBytes, err := json.Marshal(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stub.PutState(key, walletAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return walletAsBytes, nil
+}
+
+// redactMobileUpdates moves the MOBILE_UPDATE transactions of a wallet that
+// were written with the mobile hash in their action entity id to the id of
+// their transaction, like the later ones.
+func redactMobileUpdates(stub shim.ChaincodeStubInterface, walletID string) error {
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(WalletTransactionObjectType, []string{walletID, MobileUpdateAction})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	var keys []string
+	var transactions []*WalletTransaction
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+
+		var transaction = new(WalletTransaction)
+		err = unmarshalDocument(stub, queryResponse.Value, transaction)
+		if err != nil {
+			return err
+		}
+
+		if transaction.ActionEntityID != transaction.TxID {
+			keys = append(keys, queryResponse.Key)
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	for i, transaction := range transactions {
+		err = stub.DelState(keys[i])
+		if err != nil {
+			return err
+		}
+
+		transaction.ActionEntityID = transaction.TxID
+		key, err := stub.CreateCompositeKey(WalletTransactionObjectType, []string{walletID, MobileUpdateAction, transaction.ActionEntityID})
+		if err != nil {
+			return err
+		}
+
+		asBytes, err := json.Marshal(transaction)
+		if err != nil {
+			return err
+		}
+
+		err = stub.PutState(key, asBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+var (
+	testMobile   = "+4915112345678"
+	testSalt     = []byte("0123456789abcdef")
+	testIndexKey = []byte("0123456789abcdef0123456789abcdef")
+)
+
+func mobileTransient(mobile string, salt []byte) map[string][]byte {
+	return map[string][]byte{TransientMobile: []byte(mobile), TransientSalt: salt}
+}
+
+// assertMobileNotPublic fails when a mobile identifier is in the world state.
+func assertMobileNotPublic(t *testing.T, f *fixture, mobile string) {
+	for key, value := range f.State {
+		assert(t, !bytes.Contains(value, []byte(mobile)), "mobile found in public state %q", key)
+	}
+}
+
+func TestWalletMobile(t *testing.T) {
+	t.Log("Test mobiles are kept in the private collection and only committed to publicly")
+	f := newFixture(t).withWallet("w3", defaultMobileHash, 10).build()
+	putLegacyMobileUpdate(t, f, "w3", defaultMobileHash)
+
+	response := f.invokeWithTransient(map[string][]byte{TransientIndexKey: testIndexKey}, "setMobileIndexKey")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invokeWithTransient(mobileTransient(testMobile, testSalt), "createWallet", "w1", "", "10")
+	equals(t, int32(200), response.GetStatus())
+	equals(t, mobileCommitment(testMobile, testSalt), f.wallet("w1").MobileHash)
+	assertMobileNotPublic(t, f, testMobile)
+
+	response = f.invokeWithTransient(mobileTransient(testMobile, nil), "getWalletByMobileHash")
+	equals(t, int32(200), response.GetStatus())
+
+	var wallet = new(Wallet)
+	ok(t, json.Unmarshal(response.GetPayload(), wallet))
+	equals(t, "w1", wallet.ID)
+
+	// ---- Update ----
+	response = f.invokeWithTransient(mobileTransient("+4915187654321", []byte("fedcba9876543210")), "updateWalletMobileHash", "w1")
+	equals(t, int32(200), response.GetStatus())
+	equals(t, mobileCommitment("+4915187654321", []byte("fedcba9876543210")), f.wallet("w1").MobileHash)
+
+	response = f.invokeWithTransient(mobileTransient(testMobile, nil), "getWalletByMobileHash")
+	equals(t, CodeNotFound.Status(), response.GetStatus())
+
+	// The released mobile can be used again
+	response = f.invokeWithTransient(mobileTransient(testMobile, testSalt), "createWallet", "w2", "", "10")
+	equals(t, int32(200), response.GetStatus())
+
+	// ---- Purge ----
+	response = f.invoke("purgeWalletMobile", "w2")
+	equals(t, int32(200), response.GetStatus())
+	equals(t, "", f.wallet("w2").MobileHash)
+
+	response = f.invokeWithTransient(mobileTransient(testMobile, nil), "getWalletByMobileHash")
+	equals(t, CodeNotFound.Status(), response.GetStatus())
+
+	for key, value := range f.PvtState[MobileCollection] {
+		assert(t, !bytes.Contains(value, []byte(testMobile)), "purged mobile found in private data %q", key)
+	}
+
+	// Wallets with a legacy mobile hash can be purged as well, including
+	// the hash in their earlier mobile updates
+	response = f.invoke("purgeWalletMobile", "w3")
+	equals(t, int32(200), response.GetStatus())
+	assertMobileNotPublic(t, f, defaultMobileHash)
+
+	key, err := f.CreateCompositeKey(WalletTransactionObjectType, []string{"w3", MobileUpdateAction, "legacyTx"})
+	ok(t, err)
+	assert(t, len(f.State[key]) != 0, "redacted mobile update not found")
+}
+
+// putLegacyMobileUpdate stores a MOBILE_UPDATE transaction the way it was
+// written before, with the mobile hash in its action entity id.
+func putLegacyMobileUpdate(t *testing.T, f *fixture, walletID, mobileHash string) {
+	transaction := WalletTransaction{ObjectType: WalletTransactionObjectType, TxID: "legacyTx", Type: "mobile update",
+		WalletID: walletID, Action: MobileUpdateAction, ActionEntityID: mobileHash + " | 2019-10-01 10:00:00", Customer: DefaultCustomer}
+	key, err := f.CreateCompositeKey(WalletTransactionObjectType, []string{walletID, transaction.Action, transaction.ActionEntityID})
+	ok(t, err)
+
+	asBytes, err := json.Marshal(transaction)
+	ok(t, err)
+
+	f.MockTransactionStart("legacy")
+	ok(t, f.PutState(key, asBytes))
+	f.MockTransactionEnd("legacy")
+}
+
+func TestPurgeLegacyMobileHashes(t *testing.T) {
+	t.Log("Test purging the mobile hashes of wallets registered before the private collection")
+	f := newFixture(t).withWallet("w1", "hash1", 10).withWallet("w2", "hash2", 10).build()
+
+	response := f.invokeWithTransient(map[string][]byte{TransientIndexKey: testIndexKey}, "setMobileIndexKey")
+	equals(t, int32(200), response.GetStatus())
+
+	// w2 registers its mobile, w1 does not
+	response = f.invokeWithTransient(mobileTransient(testMobile, testSalt), "updateWalletMobileHash", "w2")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("getMigrationPage", WalletObjectType)
+	equals(t, int32(200), response.GetStatus())
+
+	var page = new(MigrationPage)
+	ok(t, json.Unmarshal(response.GetPayload(), page))
+
+	response = f.invoke("purgeLegacyMobileHashes", page.Keys...)
+	equals(t, int32(200), response.GetStatus())
+
+	var progress = new(MigrationProgress)
+	ok(t, json.Unmarshal(response.GetPayload(), progress))
+	equals(t, 1, progress.Migrated)
+	equals(t, "", f.wallet("w1").MobileHash)
+	equals(t, mobileCommitment(testMobile, testSalt), f.wallet("w2").MobileHash)
+	assertMobileNotPublic(t, f, "hash1")
+	assertMobileNotPublic(t, f, "hash2")
+
+	response = f.invoke("getWalletByMobileHash", "hash1")
+	equals(t, CodeNotFound.Status(), response.GetStatus())
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestWalletMobileNegative(t *testing.T) {
+	t.Log("Test private mobile Negative")
+	f := newFixture(t).build()
+
+	response := f.invokeWithTransient(mobileTransient(testMobile, testSalt), "createWallet", "w1")
+	equals(t, CodeInternal.Status(), response.GetStatus())
+	equals(t, errMobileIndexKeyMissing.Message, response.GetMessage())
+
+	response = f.invokeWithTransient(map[string][]byte{TransientIndexKey: []byte("short")}, "setMobileIndexKey")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	response = f.invokeWithTransient(map[string][]byte{TransientIndexKey: testIndexKey}, "setMobileIndexKey")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invokeWithTransient(map[string][]byte{TransientIndexKey: testIndexKey}, "setMobileIndexKey")
+	equals(t, CodeAlreadyExists.Status(), response.GetStatus())
+
+	response = f.invokeWithTransient(mobileTransient(testMobile, []byte("short")), "createWallet", "w1")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	response = f.invoke("createWallet", "w1")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+	equals(t, errMissingMobile.Message, response.GetMessage())
+
+	response = f.invokeWithTransient(mobileTransient(testMobile, testSalt), "createWallet", "w1")
+	equals(t, int32(200), response.GetStatus())
+
+	// The same mobile with another salt is still the same mobile
+	response = f.invokeWithTransient(mobileTransient(testMobile, []byte("fedcba9876543210")), "createWallet", "w2")
+	equals(t, CodeAlreadyExists.Status(), response.GetStatus())
+	equals(t, errMobileInUse.Message, response.GetMessage())
+
+	// A mobile hash argument is rejected once the index key is set
+	response = f.invoke("createWallet", "w3", "hash3")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+	equals(t, errMobileHashArgument.Message, response.GetMessage())
+
+	response = f.invoke("updateWalletMobileHash", "w1", "hash3")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+	equals(t, errMobileHashArgument.Message, response.GetMessage())
+
+	response = f.invoke("purgeWalletMobile", "unknown")
+	equals(t, CodeNotFound.Status(), response.GetStatus())
+
+	response = f.invoke("purgeLegacyMobileHashes", "%%%")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	response = f.invokeAs(f.as("Org2MSP", "user2"), "purgeLegacyMobileHashes")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+}
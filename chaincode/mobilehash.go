@@ -0,0 +1,142 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// The mobile hash index maps the mobile hash of every wallet to its wallet
+// id, so that a mobile hash belongs to at most one wallet and a wallet can be
+// found by its mobile hash without a rich query. Wallets created before the
+// index existed are added to it by migrate. Wallets whose mobile identifier
+// is kept in the private collection are indexed by their commitment; see
+// mobile.go.
+
+var errMobileHashInUse = newError(CodeAlreadyExists, "Mobile hash is already used by another wallet")
+
+func mobileHashKey(stub shim.ChaincodeStubInterface, mobileHash string) (string, error) {
+	return stub.CreateCompositeKey(MobileHashIndex, []string{mobileHash})
+}
+
+// putMobileHashIndex points the mobile hash of wallet at it and removes the
+// entry of its previous mobile hash. It fails when another wallet already
+// uses the mobile hash. Empty mobile hashes are not indexed.
+func putMobileHashIndex(stub shim.ChaincodeStubInterface, previous string, wallet *Wallet) error {
+
+	if wallet.MobileHash == previous {
+		return nil
+	}
+
+	if wallet.MobileHash != "" {
+		key, err := mobileHashKey(stub, wallet.MobileHash)
+		if err != nil {
+			return err
+		}
+
+		walletID, err := stub.GetState(key)
+		if err != nil {
+			return err
+		}
+
+		if len(walletID) != 0 && string(walletID) != wallet.ID {
+			return errMobileHashInUse
+		}
+
+		err = stub.PutState(key, []byte(wallet.ID))
+		if err != nil {
+			return err
+		}
+	}
+
+	if previous != "" {
+		key, err := mobileHashKey(stub, previous)
+		if err != nil {
+			return err
+		}
+
+		walletID, err := stub.GetState(key)
+		if err != nil {
+			return err
+		}
+
+		if string(walletID) == wallet.ID {
+			return stub.DelState(key)
+		}
+	}
+
+	return nil
+}
+
+// indexWallet adds a stored wallet to the mobile hash index during a
+// migration. Duplicates written before the index existed keep the first
+// wallet in the index and are logged so they can be resolved.
+func indexWallet(stub shim.ChaincodeStubInterface, data []byte) error {
+
+	var wallet = new(Wallet)
+	err := json.Unmarshal(data, wallet)
+	if err != nil {
+		return err
+	}
+
+	if wallet.MobileHash == "" {
+		return nil
+	}
+
+	key, err := mobileHashKey(stub, wallet.MobileHash)
+	if err != nil {
+		return err
+	}
+
+	walletID, err := stub.GetState(key)
+	if err != nil {
+		return err
+	}
+
+	if len(walletID) == 0 {
+		return stub.PutState(key, []byte(wallet.ID))
+	}
+
+	if string(walletID) != wallet.ID {
+		logger.Warning("Mobile hash of wallet " + wallet.ID + " is already used by wallet " + string(walletID))
+	}
+	return nil
+}
+
+func (s *SmartContract) getWalletByMobileHash(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	var walletID []byte
+	if len(args) >= 1 && args[0] != "" {
+		key, err := mobileHashKey(stub, args[0])
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		walletID, err = stub.GetState(key)
+		if err != nil {
+			return errorResponse(err)
+		}
+	} else {
+		mobile, _, found, err := getTransientMobile(stub)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if !found {
+			return errorResponse(errMissingMobile)
+		}
+
+		id, err := getWalletIDByMobile(stub, mobile)
+		if err != nil {
+			return errorResponse(err)
+		}
+		walletID = []byte(id)
+	}
+
+	if len(walletID) == 0 {
+		return errorResponse(newError(CodeNotFound, "No wallet with the given mobile found"))
+	}
+
+	return s.getWallet(stub, []string{string(walletID)})
+}
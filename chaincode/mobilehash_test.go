@@ -0,0 +1,73 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestMobileHashIndex(t *testing.T) {
+	t.Log("Test a mobile hash belongs to one wallet and finds it")
+	f := newFixture(t).withWallet("w1", "hash1", 10).build()
+
+	equals(t, f.wallet("w1"), f.walletByMobileHash("hash1"))
+
+	response := f.invoke("getWalletByMobileHash", "unknown")
+	equals(t, CodeNotFound.Status(), response.GetStatus())
+
+	response = f.invoke("createWallet", "w2", "hash1")
+	equals(t, CodeAlreadyExists.Status(), response.GetStatus())
+	equals(t, errMobileHashInUse.Message, response.GetMessage())
+
+	response = f.invoke("updateWalletMobileHash", "w1", "hash2")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("getWalletByMobileHash", "hash1")
+	equals(t, CodeNotFound.Status(), response.GetStatus())
+	equals(t, "w1", f.walletByMobileHash("hash2").ID)
+
+	// The released mobile hash can be used again
+	response = f.invoke("createWallet", "w2", "hash1")
+	equals(t, int32(200), response.GetStatus())
+	equals(t, "w2", f.walletByMobileHash("hash1").ID)
+
+	response = f.invoke("updateWalletMobileHash", "w2", "hash2")
+	equals(t, CodeAlreadyExists.Status(), response.GetStatus())
+	equals(t, "hash1", f.wallet("w2").MobileHash)
+
+	response = f.invoke("updateWalletMobileHash", "w1", "hash2")
+	equals(t, int32(200), response.GetStatus())
+	equals(t, "w1", f.walletByMobileHash("hash2").ID)
+}
+
+func TestMobileHashIndexMigration(t *testing.T) {
+	t.Log("Test migrate adds wallets written before the index to it")
+	mockStub := newInvoker(t, shim.NewMockStub("mobileHash", new(SmartContract)), "Org1MSP", "admin")
+	response := mockStub.MockInit("1", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+	putLegacyWallets(t, mockStub.MockStub, "legacy_wallet_id")
+
+	response = mockStub.MockInvoke("2", [][]byte{[]byte("getWalletByMobileHash"), []byte("legacy")})
+	equals(t, CodeNotFound.Status(), response.GetStatus())
+
+	response = mockStub.MockInvoke("3", [][]byte{[]byte("getMigrationPage"), []byte(WalletObjectType)})
+	equals(t, int32(200), response.GetStatus())
+
+	var page = new(MigrationPage)
+	err := json.Unmarshal(response.GetPayload(), page)
+	ok(t, err)
+
+	args := [][]byte{[]byte("migrate"), []byte(WalletObjectType)}
+	for _, key := range page.Keys {
+		args = append(args, []byte(key))
+	}
+	response = mockStub.MockInvoke("4", args)
+	equals(t, int32(200), response.GetStatus())
+
+	response = mockStub.MockInvoke("5", [][]byte{[]byte("getWalletByMobileHash"), []byte("legacy")})
+	equals(t, int32(200), response.GetStatus())
+
+	response = mockStub.MockInvoke("6", [][]byte{[]byte("createWallet"), []byte("w1"), []byte("legacy")})
+	equals(t, CodeAlreadyExists.Status(), response.GetStatus())
+}
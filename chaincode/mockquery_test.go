@@ -0,0 +1,422 @@
+package chaincode
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// mangoQuery is the subset of a CouchDB Mango query the mock evaluates.
+// use_index is accepted and ignored, like CouchDB does for unknown indexes.
+type mangoQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []interface{}          `json:"sort"`
+	Limit    *int                   `json:"limit"`
+	Skip     int                    `json:"skip"`
+	Bookmark string                 `json:"bookmark"`
+	UseIndex interface{}            `json:"use_index"`
+}
+
+type mangoSortField struct {
+	field      string
+	descending bool
+}
+
+// GetQueryResult evaluates a CouchDB query over the state of the MockStub,
+// which does not implement rich queries itself.
+func (s *invokerStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	if s.queryErr != nil {
+		return nil, s.queryErr
+	}
+
+	results, _, err := s.queryWithBookmark(query)
+	if err != nil {
+		return nil, err
+	}
+	return &mockQueryIterator{results: results}, nil
+}
+
+// queryWithBookmark evaluates query and returns the selected page with the
+// bookmark of the next one.
+func (s *invokerStub) queryWithBookmark(query string) ([]*queryresult.KV, string, error) {
+	var mango mangoQuery
+	err := json.Unmarshal([]byte(query), &mango)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if mango.Selector == nil {
+		return nil, "", errors.New("query must contain a selector")
+	}
+
+	sortFields, err := parseMangoSort(mango.Sort)
+	if err != nil {
+		return nil, "", err
+	}
+
+	type match struct {
+		kv  *queryresult.KV
+		doc map[string]interface{}
+	}
+	var matches []match
+	for key, value := range s.State {
+		var doc map[string]interface{}
+		if json.Unmarshal(value, &doc) != nil {
+			continue
+		}
+
+		matched, err := matchMango(doc, mango.Selector)
+		if err != nil {
+			return nil, "", err
+		}
+		if matched {
+			matches = append(matches, match{&queryresult.KV{Key: key, Value: value}, doc})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		for _, sortField := range sortFields {
+			a, _ := lookupField(matches[i].doc, sortField.field)
+			b, _ := lookupField(matches[j].doc, sortField.field)
+			if c := collate(a, b); c != 0 {
+				return (c < 0) != sortField.descending
+			}
+		}
+		return matches[i].kv.Key < matches[j].kv.Key
+	})
+
+	start := 0
+	if mango.Bookmark != "" {
+		lastKey, err := base64.StdEncoding.DecodeString(mango.Bookmark)
+		if err != nil {
+			return nil, "", err
+		}
+		for start < len(matches) && matches[start].kv.Key != string(lastKey) {
+			start++
+		}
+		start++
+	}
+	start += mango.Skip
+
+	limit := 25
+	if mango.Limit != nil {
+		limit = *mango.Limit
+	}
+
+	var results []*queryresult.KV
+	bookmark := ""
+	for i := start; i < len(matches) && len(results) < limit; i++ {
+		results = append(results, matches[i].kv)
+		bookmark = base64.StdEncoding.EncodeToString([]byte(matches[i].kv.Key))
+	}
+	return results, bookmark, nil
+}
+
+func parseMangoSort(sortSpec []interface{}) ([]mangoSortField, error) {
+	var fields []mangoSortField
+	for _, entry := range sortSpec {
+		switch entry := entry.(type) {
+		case string:
+			fields = append(fields, mangoSortField{field: entry})
+		case map[string]interface{}:
+			for field, direction := range entry {
+				switch direction {
+				case "asc":
+					fields = append(fields, mangoSortField{field: field})
+				case "desc":
+					fields = append(fields, mangoSortField{field: field, descending: true})
+				default:
+					return nil, fmt.Errorf("invalid sort direction %v", direction)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid sort %v", entry)
+		}
+	}
+	return fields, nil
+}
+
+// matchMango reports whether doc satisfies selector.
+func matchMango(doc map[string]interface{}, selector map[string]interface{}) (bool, error) {
+	for field, condition := range selector {
+		switch field {
+		case "$and", "$or":
+			selectors, isArray := condition.([]interface{})
+			if !isArray {
+				return false, fmt.Errorf("%s expects an array", field)
+			}
+
+			matchedAny := false
+			matchedAll := true
+			for _, sub := range selectors {
+				subSelector, isObject := sub.(map[string]interface{})
+				if !isObject {
+					return false, fmt.Errorf("%s expects an array of selectors", field)
+				}
+
+				matched, err := matchMango(doc, subSelector)
+				if err != nil {
+					return false, err
+				}
+				matchedAny = matchedAny || matched
+				matchedAll = matchedAll && matched
+			}
+
+			if (field == "$and" && !matchedAll) || (field == "$or" && !matchedAny) {
+				return false, nil
+			}
+		default:
+			value, found := lookupField(doc, field)
+			matched, err := matchCondition(value, found, condition)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// matchCondition applies the operators of a field condition, or equality
+// when the condition is a plain value.
+func matchCondition(value interface{}, found bool, condition interface{}) (bool, error) {
+	operators, isObject := condition.(map[string]interface{})
+	if !isObject || !hasOperators(operators) {
+		return found && collate(value, condition) == 0, nil
+	}
+
+	for operator, operand := range operators {
+		var matched bool
+		switch operator {
+		case "$exists":
+			matched = found == operand
+		case "$eq":
+			matched = found && collate(value, operand) == 0
+		case "$ne":
+			matched = found && collate(value, operand) != 0
+		case "$gt":
+			matched = found && collate(value, operand) > 0
+		case "$gte":
+			matched = found && collate(value, operand) >= 0
+		case "$lt":
+			matched = found && collate(value, operand) < 0
+		case "$lte":
+			matched = found && collate(value, operand) <= 0
+		case "$in", "$nin":
+			candidates, isArray := operand.([]interface{})
+			if !isArray {
+				return false, fmt.Errorf("%s expects an array", operator)
+			}
+
+			in := false
+			for _, candidate := range candidates {
+				in = in || (found && collate(value, candidate) == 0)
+			}
+			matched = in == (operator == "$in")
+		default:
+			return false, fmt.Errorf("unsupported operator %s", operator)
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func hasOperators(condition map[string]interface{}) bool {
+	for key := range condition {
+		if strings.HasPrefix(key, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupField resolves a dotted field path in doc.
+func lookupField(doc map[string]interface{}, field string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, part := range strings.Split(field, ".") {
+		object, isObject := value.(map[string]interface{})
+		if !isObject {
+			return nil, false
+		}
+
+		var found bool
+		value, found = object[part]
+		if !found {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// collate compares two JSON values in CouchDB order: null, false, true,
+// numbers, strings, arrays and objects.
+func collate(a, b interface{}) int {
+	rankA, rankB := collationRank(a), collationRank(b)
+	if rankA != rankB {
+		return rankA - rankB
+	}
+
+	switch a := a.(type) {
+	case bool:
+		return collationRank(a) - collationRank(b)
+	case float64:
+		b := b.(float64)
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case nil:
+		return 0
+	}
+
+	aBytes, _ := json.Marshal(a)
+	bBytes, _ := json.Marshal(b)
+	return strings.Compare(string(aBytes), string(bBytes))
+}
+
+func collationRank(value interface{}) int {
+	switch value := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	}
+	return 6
+}
+
+// GetStateByPartialCompositeKeyWithPagination pages over the keys of a
+// partial composite key like LevelDB does: the bookmark is the key the next
+// page starts at, and is empty after the last page.
+func (s *invokerStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *sc.QueryResponseMetadata, error) {
+	iterator, err := s.MockStub.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iterator.Close()
+
+	var results []*queryresult.KV
+	var metadata = new(sc.QueryResponseMetadata)
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if kv.Key < bookmark {
+			continue
+		}
+
+		if int32(len(results)) == pageSize {
+			metadata.Bookmark = kv.Key
+			break
+		}
+		results = append(results, kv)
+	}
+
+	metadata.FetchedRecordsCount = int32(len(results))
+	return &mockQueryIterator{results: results}, metadata, nil
+}
+
+type mockQueryIterator struct {
+	results []*queryresult.KV
+	closed  bool
+}
+
+func (iter *mockQueryIterator) HasNext() bool {
+	return !iter.closed && len(iter.results) > 0
+}
+
+func (iter *mockQueryIterator) Next() (*queryresult.KV, error) {
+	if !iter.HasNext() {
+		return nil, errors.New("mockQueryIterator.Next() called without a next result")
+	}
+
+	kv := iter.results[0]
+	iter.results = iter.results[1:]
+	return kv, nil
+}
+
+func (iter *mockQueryIterator) Close() error {
+	iter.closed = true
+	return nil
+}
+
+func TestMockQuery(t *testing.T) {
+	t.Log("Test the rich query engine of the test stub")
+	f := newFixture(t).build()
+	f.MockTransactionStart("state")
+	for key, value := range map[string]string{
+		"a": `{"docType":"doc","n":1,"tag":"x","sub":{"v":true}}`,
+		"b": `{"docType":"doc","n":2,"tag":"y"}`,
+		"c": `{"docType":"doc","n":3,"tag":"x"}`,
+		"d": `{"docType":"other","n":4}`,
+	} {
+		ok(t, f.PutState(key, []byte(value)))
+	}
+	f.MockTransactionEnd("state")
+
+	tests := []struct {
+		query string
+		keys  []string
+	}{
+		{`{"selector":{"docType":"doc"},"sort":["n"]}`, []string{"a", "b", "c"}},
+		{`{"selector":{"docType":{"$eq":"doc"},"n":{"$gt":1}},"sort":[{"n":"desc"}]}`, []string{"c", "b"}},
+		{`{"selector":{"n":{"$gte":2,"$lt":4}},"sort":["n"]}`, []string{"b", "c"}},
+		{`{"selector":{"n":{"$lte":1}}}`, []string{"a"}},
+		{`{"selector":{"tag":{"$in":["y","z"]}}}`, []string{"b"}},
+		{`{"selector":{"$or":[{"n":1},{"docType":"other"}]},"sort":["n"]}`, []string{"a", "d"}},
+		{`{"selector":{"$and":[{"tag":"x"},{"n":{"$ne":1}}]}}`, []string{"c"}},
+		{`{"selector":{"sub.v":true}}`, []string{"a"}},
+		{`{"selector":{"n":{"$gt":0},"tag":{"$exists":false}}}`, []string{"d"}},
+		{`{"selector":{"docType":"doc"},"sort":["n"],"limit":1,"skip":1}`, []string{"b"}},
+	}
+
+	for _, test := range tests {
+		results, _, err := f.queryWithBookmark(test.query)
+		ok(t, err)
+
+		var keys []string
+		for _, kv := range results {
+			keys = append(keys, kv.Key)
+		}
+		equals(t, test.keys, keys)
+	}
+
+	// Bookmarks continue after the last result of the previous page
+	var keys []string
+	bookmark := ""
+	for page := 0; page < 3; page++ {
+		results, next, err := f.queryWithBookmark(`{"selector":{"docType":"doc"},"sort":["n"],"limit":1,"bookmark":"` + bookmark + `"}`)
+		ok(t, err)
+		equals(t, 1, len(results))
+		keys = append(keys, results[0].Key)
+		bookmark = next
+	}
+	equals(t, []string{"a", "b", "c"}, keys)
+
+	_, _, err := f.queryWithBookmark(`{"selector":{"n":{"$regex":"1"}}}`)
+	assert(t, err != nil, "expected an error for an unsupported operator")
+}
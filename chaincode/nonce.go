@@ -0,0 +1,107 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// WalletNonce is the last nonce used by the signed operations of a wallet.
+// Every signed operation must carry the next nonce, Nonce + 1, so that a
+// captured operation cannot be replayed, even with another actionEntityId,
+// and signed operations run in the order they were signed. Wallets that
+// never signed an operation have nonce 0.
+type WalletNonce struct {
+	ObjectType    string `json:"docType"`
+	SchemaVersion int    `json:"schemaVersion"`
+	WalletID      string `json:"walletId"`
+	Nonce         int64  `json:"nonce"`
+}
+
+var errNonceUsed = newError(CodeDoubleHit, "Nonce has already been used")
+
+func getWalletNonceObject(stub shim.ChaincodeStubInterface, walletID string) (*WalletNonce, error) {
+
+	key, err := stub.CreateCompositeKey(WalletNonceObjectType, []string{walletID})
+	if err != nil {
+		return nil, err
+	}
+
+	asBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var walletNonce = new(WalletNonce)
+	if len(asBytes) == 0 {
+		walletNonce.ObjectType = WalletNonceObjectType
+		walletNonce.SchemaVersion = schemaVersion(WalletNonceObjectType)
+		walletNonce.WalletID = walletID
+		return walletNonce, nil
+	}
+
+	err = unmarshalDocument(stub, asBytes, walletNonce)
+	if err != nil {
+		return nil, err
+	}
+	return walletNonce, nil
+}
+
+// useWalletNonce records nonce as the last nonce of a wallet. It fails when
+// the nonce is not the next one: DOUBLE_HIT for a nonce that was used, and
+// INVALID_ARGUMENT for a nonce that skips ahead.
+func useWalletNonce(stub shim.ChaincodeStubInterface, walletID string, nonce int64) error {
+
+	walletNonce, err := getWalletNonceObject(stub, walletID)
+	if err != nil {
+		return err
+	}
+
+	next := walletNonce.Nonce + 1
+	if nonce < next {
+		return errNonceUsed
+	}
+	if nonce > next {
+		return newError(CodeInvalidArgument, "Invalid argument nonce: expecting "+strconv.FormatInt(next, 10))
+	}
+
+	walletNonce.Nonce = nonce
+
+	key, err := stub.CreateCompositeKey(WalletNonceObjectType, []string{walletID})
+	if err != nil {
+		return err
+	}
+
+	asBytes, err := json.Marshal(walletNonce)
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(key, asBytes)
+}
+
+func (s *SmartContract) getWalletNonce(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	_, err := getWalletObject(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	walletNonce, err := getWalletNonceObject(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	asBytes, err := json.Marshal(walletNonce)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
@@ -0,0 +1,60 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWalletNonce(t *testing.T) {
+	t.Log("Test signed operations carry the next nonce of their wallet")
+	f := newFixture(t).build()
+	expiry := time.Now().Add(time.Hour)
+
+	response := f.invoke("createWallet", "w1", "hash1", "100", DefaultAction, "w1", DefaultCustomer, testWalletKey)
+	equals(t, int32(200), response.GetStatus())
+
+	equals(t, int64(0), f.walletNonce("w1").Nonce)
+
+	args := []string{"w1", "10", "PREDICTION", "P_1"}
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, testEd25519Key, "spendCoins", 1, expiry, args...)...)...)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, int64(1), f.walletNonce("w1").Nonce)
+
+	args = []string{"w1", "10", "PREDICTION", "P_2"}
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, testEd25519Key, "spendCoins", 2, expiry, args...)...)...)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, int64(2), f.walletNonce("w1").Nonce)
+	equals(t, 80.0, f.wallet("w1").Amount)
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestWalletNonceNegative(t *testing.T) {
+	t.Log("Test reused and out of order nonces are rejected")
+	f := newFixture(t).build()
+	expiry := time.Now().Add(time.Hour)
+
+	response := f.invoke("createWallet", "w1", "hash1", "100", DefaultAction, "w1", DefaultCustomer, testWalletKey)
+	equals(t, int32(200), response.GetStatus())
+
+	args := []string{"w1", "10", "PREDICTION", "P_1"}
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, testEd25519Key, "spendCoins", 1, expiry, args...)...)...)
+	equals(t, int32(200), response.GetStatus())
+
+	// A replay with another actionEntityId is not a double hit of the
+	// action, but of the nonce
+	args = []string{"w1", "10", "PREDICTION", "P_2"}
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, testEd25519Key, "spendCoins", 1, expiry, args...)...)...)
+	equals(t, CodeDoubleHit.Status(), response.GetStatus())
+	equals(t, errNonceUsed.Message, response.GetMessage())
+
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, testEd25519Key, "spendCoins", 3, expiry, args...)...)...)
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+	equals(t, "Invalid argument nonce: expecting 2", response.GetMessage())
+
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), "x", "1", "c2lnbmF0dXJl")...)
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	equals(t, int64(1), f.walletNonce("w1").Nonce)
+	equals(t, 90.0, f.wallet("w1").Amount)
+}
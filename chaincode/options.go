@@ -0,0 +1,225 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+type Options struct {
+	ObjectType    string  `json:"docType"`
+	Registration  float64 `json:"registration"`
+	Customer      string  `json:"customer"`
+	SchemaVersion int     `json:"schemaVersion"`
+	MaxSupply     float64 `json:"maxSupply,omitempty"`
+
+	// Treasury governance, read from the default customer only
+	ApprovalLimit     float64  `json:"approvalLimit,omitempty"`
+	ApprovalThreshold int      `json:"approvalThreshold,omitempty"`
+	AdminMSPs         []string `json:"adminMSPs,omitempty"`
+	ProposalTTL       int64    `json:"proposalTTL,omitempty"`
+
+	// couchdb, leveldb or empty to detect the state database per search
+	StateDatabase string `json:"stateDatabase,omitempty"`
+}
+
+// setOptions sets the options of a customer. The registration amount and the
+// customer may be passed by position; the other settings are only taken by
+// name, in a JSON object argument such as
+//
+//	{"registration": 110, "maxSupply": 1000000000, "adminMSPs": ["Org1MSP"]}
+//
+// Settings that are not passed keep their stored value.
+func (s *SmartContract) setOptions(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	customer := DefaultCustomer
+	if len(args) >= 2 {
+		customer = args[1]
+	}
+
+	key, err := stub.CreateCompositeKey(OptionsObjectType, []string{OptionsID, customer})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Start from the stored options so that settings which are not passed,
+	// such as maxSupply, survive an update of the registration amount.
+	var options = new(Options)
+	asBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if len(asBytes) != 0 {
+		err = unmarshalDocument(stub, asBytes, options)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	options.ObjectType = OptionsObjectType
+	options.SchemaVersion = schemaVersion(OptionsObjectType)
+	options.Registration, err = parseAmount(args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	options.Customer = customer
+
+	if len(args) >= 3 && args[2] != "" {
+		options.MaxSupply, err = strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			return errorResponse(newError(CodeInvalidArgument, err.Error()))
+		}
+	}
+
+	if len(args) >= 4 && args[3] != "" {
+		options.ApprovalLimit, err = strconv.ParseFloat(args[3], 64)
+		if err != nil {
+			return errorResponse(newError(CodeInvalidArgument, err.Error()))
+		}
+	}
+
+	if len(args) >= 5 && args[4] != "" {
+		options.ApprovalThreshold, err = strconv.Atoi(args[4])
+		if err != nil {
+			return errorResponse(newError(CodeInvalidArgument, err.Error()))
+		}
+	}
+
+	if len(args) >= 6 && args[5] != "" {
+		options.AdminMSPs = splitList(args[5])
+		if len(options.AdminMSPs) == 0 {
+			return errorResponse(newError(CodeInvalidArgument, "Invalid argument adminMSPs: expecting at least one MSP ID"))
+		}
+	}
+
+	if len(args) >= 7 && args[6] != "" {
+		options.ProposalTTL, err = strconv.ParseInt(args[6], 10, 64)
+		if err != nil {
+			return errorResponse(newError(CodeInvalidArgument, err.Error()))
+		}
+	}
+
+	if len(args) >= 8 && args[7] != "" {
+		switch args[7] {
+		case StateDatabaseCouchDB, StateDatabaseLevelDB:
+			options.StateDatabase = args[7]
+		case "auto":
+			options.StateDatabase = ""
+		default:
+			return errorResponse(newError(CodeInvalidArgument, "Invalid state database "+args[7]+". Expecting couchdb, leveldb or auto"))
+		}
+	}
+
+	err = validateApprovalThreshold(options)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	asBytes, err = putOptions(stub, options)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+// validateApprovalThreshold fails when a proposal could never collect the
+// approvals of ApprovalThreshold distinct admin organizations.
+func validateApprovalThreshold(options *Options) error {
+
+	if options.ApprovalThreshold < 0 || options.ApprovalThreshold > len(options.AdminMSPs) {
+		return newError(CodeInvalidArgument, "Invalid argument approvalThreshold: expecting at most "+strconv.Itoa(len(options.AdminMSPs))+", the number of adminMSPs")
+	}
+	return nil
+}
+
+func putOptions(stub shim.ChaincodeStubInterface, options *Options) ([]byte, error) {
+
+	key, err := stub.CreateCompositeKey(OptionsObjectType, []string{OptionsID, options.Customer})
+	if err != nil {
+		return nil, err
+	}
+
+	asBytes, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stub.PutState(key, asBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return asBytes, nil
+}
+
+func (s *SmartContract) getOptionsAsByte(stub shim.ChaincodeStubInterface, customer string) ([]byte, error) {
+
+	if len(customer) == 0 {
+		customer = DefaultCustomer
+	}
+
+	key, err := stub.CreateCompositeKey(OptionsObjectType, []string{OptionsID, customer})
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := stub.GetState(key)
+	if options == nil && customer != DefaultCustomer {
+		return s.getOptionsAsByte(stub, "")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	options, _, err = upgradeDocument(stub, options)
+	return options, err
+}
+
+func (s *SmartContract) getOptions(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	customer := DefaultCustomer
+	if len(args) >= 1 {
+		customer = args[0]
+	}
+
+	options, err := s.getOptionsAsByte(stub, customer)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(options)
+}
+
+func (s *SmartContract) getOptionsObject(stub shim.ChaincodeStubInterface, customer string) (Options, error) {
+
+	var options = new(Options)
+	var optionsBytes, err = s.getOptionsAsByte(stub, customer)
+	if err != nil {
+		return *options, err
+	}
+
+	err = unmarshalDocument(stub, optionsBytes, options)
+	return *options, err
+}
+
+// splitList parses a comma separated argument, dropping empty entries.
+func splitList(arg string) []string {
+
+	var values []string
+	for _, value := range strings.Split(arg, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
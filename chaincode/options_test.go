@@ -0,0 +1,73 @@
+package chaincode
+
+import (
+	"testing"
+)
+
+func TestGetOptions(t *testing.T) {
+	t.Log("Test getOptions")
+	f := newFixture(t).build()
+
+	options := f.options(DefaultCustomer)
+	equals(t, float64(DefaultRegistrationAmount), options.Registration)
+	equals(t, DefaultCustomer, options.Customer)
+
+	// Customers without options of their own get the default options
+	options = f.options("unknown-customer")
+	equals(t, DefaultCustomer, options.Customer)
+}
+
+func TestSetOptions(t *testing.T) {
+	t.Log("Test setOptions")
+	f := newFixture(t).build()
+	response := f.invoke("setOptions", "200", "New-ninjastack")
+	equals(t, int32(200), response.GetStatus())
+
+	options := f.options("New-ninjastack")
+	equals(t, float64(200), options.Registration)
+	equals(t, "New-ninjastack", options.Customer)
+
+	// Settings that are not passed are kept
+	response = f.invoke("setOptions", `{"registration":110,"maxSupply":1000}`)
+	equals(t, int32(200), response.GetStatus())
+	response = f.invoke("setOptions", "120")
+	equals(t, int32(200), response.GetStatus())
+
+	options = f.options(DefaultCustomer)
+	equals(t, float64(120), options.Registration)
+	equals(t, float64(1000), options.MaxSupply)
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestFalseCase(t *testing.T) {
+	t.Log("Test false cases")
+	f := newFixture(t).build()
+	response := f.invoke("getOption", DefaultCustomer)
+	equals(t, int32(400), response.GetStatus())
+
+	response = f.invoke("setOptions")
+	equals(t, int32(400), response.GetStatus())
+}
+
+func TestSetOptionsNegative(t *testing.T) {
+	t.Log("Test setOptions Negative")
+	f := newFixture(t).withAdmins("AdminMSP").build()
+	response := f.invoke("setOptions", "200", "New-ninjastack")
+	equals(t, int32(403), response.GetStatus())
+
+	response = f.invokeAs(f.as("AdminMSP", "admin1"), "setOptions", "200", "New-ninjastack")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invokeAs(f.as("AdminMSP", "admin1"), "setOptions", `{"registration":200,"maxSupply":"many"}`)
+	equals(t, int32(400), response.GetStatus())
+
+	for _, registration := range []string{"-5", "NaN", "Inf"} {
+		response = f.invokeAs(f.as("AdminMSP", "admin1"), "setOptions", registration, "New-ninjastack")
+		equals(t, int32(400), response.GetStatus())
+	}
+
+	// Settings beyond the registration and customer are only taken by name
+	response = f.invokeAs(f.as("AdminMSP", "admin1"), "setOptions", "200", "New-ninjastack", "1000")
+	equals(t, int32(400), response.GetStatus())
+}
@@ -0,0 +1,240 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Pause stops the functions that write to the ledger until it is lifted by
+// unpauseContract. A pause without a customer applies to the whole contract,
+// otherwise to the calls for that customer. Functions limits the pause to
+// the listed functions; when empty every function that writes is paused.
+type Pause struct {
+	ObjectType    string   `json:"docType"`
+	SchemaVersion int      `json:"schemaVersion"`
+	Customer      string   `json:"customer,omitempty"`
+	Functions     []string `json:"functions,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+	PausedBy      string   `json:"pausedBy"`
+	PauseDate     int64    `json:"pauseDate"`
+}
+
+func (s *SmartContract) pauseContract(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	var pause = new(Pause)
+	pause.ObjectType = PauseObjectType
+	pause.SchemaVersion = schemaVersion(PauseObjectType)
+
+	if len(args) >= 1 {
+		pause.Customer = args[0]
+	}
+
+	if len(args) >= 2 && args[1] != "" {
+		pause.Functions = splitList(args[1])
+	}
+
+	for _, name := range pause.Functions {
+		fn, found := registry[name]
+		if !found {
+			return errorResponse(newError(CodeInvalidArgument, "Invalid function "+name))
+		}
+		if !pausable(fn) {
+			return errorResponse(newError(CodeInvalidArgument, "Function "+name+" cannot be paused"))
+		}
+	}
+
+	if len(args) >= 3 {
+		pause.Reason = args[2]
+	}
+
+	mspID, _, err := getInvoker(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	pause.PausedBy = mspID
+
+	pause.PauseDate, err = getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	key, err := stub.CreateCompositeKey(PauseObjectType, []string{pause.Customer})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	asBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Pausing again never narrows a pause in force: the functions are added
+	// to it, and a pause of every function stays one
+	if len(asBytes) != 0 {
+		var active = new(Pause)
+		err = unmarshalDocument(stub, asBytes, active)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if len(active.Functions) == 0 || len(pause.Functions) == 0 {
+			pause.Functions = nil
+		} else {
+			for _, name := range active.Functions {
+				if !containsString(pause.Functions, name) {
+					pause.Functions = append(pause.Functions, name)
+				}
+			}
+		}
+
+		if pause.Reason == "" {
+			pause.Reason = active.Reason
+		}
+	}
+
+	asBytes, err = json.Marshal(pause)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = stub.PutState(key, asBytes)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+func (s *SmartContract) unpauseContract(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	customer := ""
+	if len(args) >= 1 {
+		customer = args[0]
+	}
+
+	key, err := stub.CreateCompositeKey(PauseObjectType, []string{customer})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	asBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if len(asBytes) == 0 {
+		return errorResponse(newError(CodeNotFound, "Contract is not paused"+pauseScope(customer)))
+	}
+
+	err = stub.DelState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(nil)
+}
+
+// getPauses returns the pauses in force, the one of the whole contract first.
+func (s *SmartContract) getPauses(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(PauseObjectType, []string{})
+	if err != nil {
+		return errorResponse(err)
+	}
+	defer resultsIterator.Close()
+
+	buffer, err := constructQueryResponseFromIterator(stub, resultsIterator)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(buffer.Bytes())
+}
+
+// pausable reports whether a function is stopped by a pause. Queries keep
+// working, and the pause functions themselves must stay callable.
+func pausable(fn *Function) bool {
+	return !fn.ReadOnly && !fn.PauseExempt
+}
+
+// checkPaused fails when a pause of the whole contract, or of one of the
+// customers that is not empty, covers the function.
+func checkPaused(stub shim.ChaincodeStubInterface, function string, customers ...string) error {
+
+	scopes := []string{""}
+	for _, customer := range customers {
+		if customer != "" && !containsString(scopes, customer) {
+			scopes = append(scopes, customer)
+		}
+	}
+
+	for _, scope := range scopes {
+		key, err := stub.CreateCompositeKey(PauseObjectType, []string{scope})
+		if err != nil {
+			return err
+		}
+
+		asBytes, err := stub.GetState(key)
+		if err != nil {
+			return err
+		}
+
+		if len(asBytes) == 0 {
+			continue
+		}
+
+		var pause = new(Pause)
+		err = unmarshalDocument(stub, asBytes, pause)
+		if err != nil {
+			return err
+		}
+
+		if len(pause.Functions) != 0 && !containsString(pause.Functions, function) {
+			continue
+		}
+
+		message := "Contract is paused" + pauseScope(scope)
+		if pause.Reason != "" {
+			message += ": " + pause.Reason
+		}
+		return newError(CodePaused, message)
+	}
+
+	return nil
+}
+
+// walletCustomer returns the customer a wallet was registered for, empty when
+// the wallet does not exist or has none.
+func walletCustomer(stub shim.ChaincodeStubInterface, walletID string) (string, error) {
+
+	key, err := stub.CreateCompositeKey(WalletObjectType, []string{walletID})
+	if err != nil {
+		return "", err
+	}
+
+	walletAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(walletAsBytes) == 0 {
+		return "", nil
+	}
+
+	var wallet = new(Wallet)
+	err = unmarshalDocument(stub, walletAsBytes, wallet)
+	if err != nil {
+		return "", err
+	}
+
+	return wallet.Customer, nil
+}
+
+func pauseScope(customer string) string {
+	if customer == "" {
+		return ""
+	}
+	return " for customer " + customer
+}
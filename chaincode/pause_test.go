@@ -0,0 +1,146 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPause(t *testing.T) {
+	t.Log("Test pauseContract stops balance movements while queries keep working")
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, 100).build()
+
+	response := f.invoke("pauseContract", "", "", "exploit")
+	equals(t, int32(200), response.GetStatus())
+
+	var pause = new(Pause)
+	ok(t, json.Unmarshal(response.GetPayload(), pause))
+	equals(t, PauseObjectType, pause.ObjectType)
+	equals(t, "Org1MSP", pause.PausedBy)
+	equals(t, "exploit", pause.Reason)
+
+	response = f.invoke("spendCoins", defaultWalletID, "10", "order", "1")
+	equals(t, CodePaused.Status(), response.GetStatus())
+	equals(t, "Contract is paused: exploit", response.GetMessage())
+
+	response = f.invoke("createWallet", "w2", "hash2")
+	equals(t, CodePaused.Status(), response.GetStatus())
+
+	response = f.invoke("mintCoins", "10", "mint", "1")
+	equals(t, CodePaused.Status(), response.GetStatus())
+
+	equals(t, float64(100), f.wallet(defaultWalletID).Amount)
+
+	response = f.invoke("unpauseContract")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("spendCoins", defaultWalletID, "10", "order", "1")
+	equals(t, int32(200), response.GetStatus())
+}
+
+func TestPauseScope(t *testing.T) {
+	t.Log("Test pauses scoped to a customer or to functions")
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, 100).build()
+
+	// ---- Customer ----
+	response := f.invoke("createWallet", "partner_wallet", "partner_hash", "10", "registration", "partner_wallet", "partner")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("pauseContract", "partner")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("purchaseCoins", defaultWalletID, "10", "order", "1", "partner")
+	equals(t, CodePaused.Status(), response.GetStatus())
+	equals(t, "Contract is paused for customer partner", response.GetMessage())
+
+	response = f.invoke("purchaseCoins", defaultWalletID, "10", "order", "2")
+	equals(t, int32(200), response.GetStatus())
+
+	// The wallets of the customer are paused whatever customer is passed
+	response = f.invoke("purchaseCoins", "partner_wallet", "10", "order", "4")
+	equals(t, CodePaused.Status(), response.GetStatus())
+
+	response = f.invoke("updateWalletMobileHash", "partner_wallet", "hash3")
+	equals(t, CodePaused.Status(), response.GetStatus())
+
+	response = f.invoke("updateWalletMobileHash", defaultWalletID, "hash2")
+	equals(t, int32(200), response.GetStatus())
+
+	// ---- Functions ----
+	response = f.invoke("pauseContract", DefaultCustomer, "spendCoins")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("spendCoins", defaultWalletID, "10", "order", "3")
+	equals(t, CodePaused.Status(), response.GetStatus())
+
+	response = f.invoke("spendCoins", `{"walletId":"`+defaultWalletID+`","amount":10,"action":"order","actionEntityId":"3"}`)
+	equals(t, CodePaused.Status(), response.GetStatus())
+
+	response = f.invoke("purchaseCoins", defaultWalletID, "10", "order", "3")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("getPauses")
+	equals(t, int32(200), response.GetStatus())
+
+	var pauses []Pause
+	ok(t, json.Unmarshal(response.GetPayload(), &pauses))
+	equals(t, 2, len(pauses))
+	equals(t, DefaultCustomer, pauses[0].Customer)
+	equals(t, []string{"spendCoins"}, pauses[0].Functions)
+	equals(t, "partner", pauses[1].Customer)
+
+	// Pausing more functions adds them to the pause
+	response = f.invoke("pauseContract", DefaultCustomer, "purchaseCoins")
+	equals(t, int32(200), response.GetStatus())
+
+	var pause = new(Pause)
+	ok(t, json.Unmarshal(response.GetPayload(), pause))
+	equals(t, []string{"purchaseCoins", "spendCoins"}, pause.Functions)
+
+	response = f.invoke("purchaseCoins", defaultWalletID, "10", "order", "5")
+	equals(t, CodePaused.Status(), response.GetStatus())
+
+	response = f.invoke("spendCoins", defaultWalletID, "10", "order", "5")
+	equals(t, CodePaused.Status(), response.GetStatus())
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestPauseNegative(t *testing.T) {
+	t.Log("Test pauseContract Negative")
+	f := newFixture(t).withAdmins("AdminMSP").build()
+
+	response := f.invoke("pauseContract")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	admin := f.as("AdminMSP", "admin1")
+	response = f.invokeAs(admin, "pauseContract")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("unpauseContract")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	// The pause functions stay callable while paused
+	response = f.invokeAs(admin, "pauseContract", "", "", "still investigating")
+	equals(t, int32(200), response.GetStatus())
+
+	// A pause of some functions does not narrow a pause of all of them
+	response = f.invokeAs(admin, "pauseContract", "", "spendCoins")
+	equals(t, int32(200), response.GetStatus())
+
+	var pause = new(Pause)
+	ok(t, json.Unmarshal(response.GetPayload(), pause))
+	equals(t, 0, len(pause.Functions))
+	equals(t, "still investigating", pause.Reason)
+
+	response = f.invokeAs(admin, "mintCoins", "10", "mint", "1")
+	equals(t, CodePaused.Status(), response.GetStatus())
+
+	response = f.invokeAs(admin, "unpauseContract", "partner")
+	equals(t, CodeNotFound.Status(), response.GetStatus())
+
+	response = f.invokeAs(admin, "pauseContract", "", "spendCoins,getTreasure")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	response = f.invokeAs(admin, "unpauseContract")
+	equals(t, int32(200), response.GetStatus())
+}
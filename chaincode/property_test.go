@@ -0,0 +1,228 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// balanceModel is the reference the random operation sequences of
+// TestRandomBalanceOperations are checked against.
+type balanceModel struct {
+	registration float64
+	treasury     float64
+	totalSupply  float64
+	wallets      map[string]float64
+	mobileHashes map[string]string
+	transactions map[string]bool
+}
+
+func newBalanceModel(treasure *Treasure, registration float64) *balanceModel {
+	return &balanceModel{
+		registration: registration,
+		treasury:     treasure.Balance,
+		totalSupply:  treasure.TotalSupply,
+		wallets:      map[string]float64{},
+		mobileHashes: map[string]string{},
+		transactions: map[string]bool{},
+	}
+}
+
+// parseAmount mirrors the amount validation of the chaincode.
+func (m *balanceModel) parseAmount(arg string) (float64, bool) {
+	amount, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) || amount < 0 {
+		return 0, false
+	}
+	return amount, true
+}
+
+func (m *balanceModel) createWallet(id, mobileHash, amountArg, action, actionEntityID string) int32 {
+	amount := m.registration
+	if amountArg != "" {
+		var valid bool
+		amount, valid = m.parseAmount(amountArg)
+		if !valid {
+			return CodeInvalidArgument.Status()
+		}
+	}
+
+	if _, found := m.wallets[id]; found {
+		return CodeAlreadyExists.Status()
+	}
+	if m.mobileHashUsed(id, mobileHash) {
+		return CodeAlreadyExists.Status()
+	}
+	if m.treasury < amount {
+		return CodeTreasuryExhausted.Status()
+	}
+
+	m.treasury -= amount
+	m.wallets[id] = amount
+	m.mobileHashes[id] = mobileHash
+	m.transactions[id+"|"+action+"|"+actionEntityID] = true
+	return 200
+}
+
+func (m *balanceModel) purchaseCoins(id, amountArg, action, actionEntityID string) int32 {
+	amount, valid := m.parseAmount(amountArg)
+	if !valid {
+		return CodeInvalidArgument.Status()
+	}
+
+	if m.treasury < amount {
+		return CodeTreasuryExhausted.Status()
+	}
+	if _, found := m.wallets[id]; !found {
+		return CodeNotFound.Status()
+	}
+	if m.transactions[id+"|"+action+"|"+actionEntityID] {
+		return CodeDoubleHit.Status()
+	}
+
+	m.treasury -= amount
+	m.wallets[id] += amount
+	m.transactions[id+"|"+action+"|"+actionEntityID] = true
+	return 200
+}
+
+func (m *balanceModel) spendCoins(id, amountArg, action, actionEntityID string) int32 {
+	amount, valid := m.parseAmount(amountArg)
+	if !valid {
+		return CodeInvalidArgument.Status()
+	}
+
+	balance, found := m.wallets[id]
+	if !found {
+		return CodeNotFound.Status()
+	}
+	if balance < amount {
+		return CodeInsufficientFunds.Status()
+	}
+	if m.transactions[id+"|"+action+"|"+actionEntityID] {
+		return CodeDoubleHit.Status()
+	}
+
+	m.treasury += amount
+	m.wallets[id] -= amount
+	m.transactions[id+"|"+action+"|"+actionEntityID] = true
+	return 200
+}
+
+func (m *balanceModel) updateWalletMobileHash(id, mobileHash string) int32 {
+	if _, found := m.wallets[id]; !found {
+		return CodeNotFound.Status()
+	}
+	if m.mobileHashUsed(id, mobileHash) {
+		return CodeAlreadyExists.Status()
+	}
+
+	m.mobileHashes[id] = mobileHash
+	return 200
+}
+
+// mobileHashUsed reports whether a wallet other than id has the mobile hash.
+func (m *balanceModel) mobileHashUsed(id, mobileHash string) bool {
+	for walletID, walletMobileHash := range m.mobileHashes {
+		if walletID != id && walletMobileHash == mobileHash {
+			return true
+		}
+	}
+	return false
+}
+
+// randomOperation picks the arguments of the next operation from small pools,
+// so that operations collide on wallets and transaction keys.
+func randomOperation(r *rand.Rand) (string, []string) {
+	walletIDs := []string{"w1", "w2", "w3", "w4", "w5"}
+	actions := []string{"order", "refund"}
+	entityIDs := []string{"1", "2", "3", "4"}
+	amounts := []string{"0", "1", "10", "50", "99", "110", "250", "1000", "-10", "NaN", "+Inf", "abc", "2.5"}
+
+	walletID := walletIDs[r.Intn(len(walletIDs))]
+	amount := amounts[r.Intn(len(amounts))]
+	action := actions[r.Intn(len(actions))]
+	entityID := entityIDs[r.Intn(len(entityIDs))]
+	mobileHash := "hash" + strconv.Itoa(r.Intn(3))
+
+	switch r.Intn(4) {
+	case 0:
+		if r.Intn(3) == 0 {
+			amount = ""
+		}
+		return "createWallet", []string{walletID, mobileHash, amount, action, entityID}
+	case 1:
+		return "purchaseCoins", []string{walletID, amount, action, entityID}
+	case 2:
+		return "spendCoins", []string{walletID, amount, action, entityID}
+	}
+	return "updateWalletMobileHash", []string{walletID, mobileHash}
+}
+
+func (m *balanceModel) apply(function string, args []string) int32 {
+	switch function {
+	case "createWallet":
+		return m.createWallet(args[0], args[1], args[2], args[3], args[4])
+	case "purchaseCoins":
+		return m.purchaseCoins(args[0], args[1], args[2], args[3])
+	case "spendCoins":
+		return m.spendCoins(args[0], args[1], args[2], args[3])
+	}
+	return m.updateWalletMobileHash(args[0], args[1])
+}
+
+// checkModel compares the ledger with the model and checks that no coins
+// were created or destroyed.
+func checkModel(t *testing.T, f *fixture, m *balanceModel, step int) {
+	treasure := f.treasure()
+	assert(t, treasure.Balance == m.treasury, "step %d: treasury balance %v, model %v", step, treasure.Balance, m.treasury)
+	assert(t, treasure.TotalSupply == m.totalSupply, "step %d: total supply changed to %v", step, treasure.TotalSupply)
+
+	sum := treasure.Balance
+	for _, id := range []string{"w1", "w2", "w3", "w4", "w5"} {
+		response := f.invoke("getWallet", id)
+		equals(t, int32(200), response.GetStatus())
+
+		balance, found := m.wallets[id]
+		if !found {
+			assert(t, len(response.GetPayload()) == 0, "step %d: wallet %s should not exist", step, id)
+			continue
+		}
+
+		var wallet = new(Wallet)
+		ok(t, json.Unmarshal(response.GetPayload(), wallet))
+		assert(t, wallet.Amount >= 0, "step %d: wallet %s has a negative balance %v", step, id, wallet.Amount)
+		assert(t, wallet.Amount == balance, "step %d: wallet %s balance %v, model %v", step, id, wallet.Amount, balance)
+		equals(t, m.mobileHashes[id], wallet.MobileHash)
+		sum += wallet.Amount
+	}
+	assert(t, sum == treasure.TotalSupply, "step %d: treasury and wallets hold %v of a supply of %v", step, sum, treasure.TotalSupply)
+}
+
+func TestRandomBalanceOperations(t *testing.T) {
+	t.Log("Test random sequences of balance operations against a reference model")
+
+	steps := 300
+	if testing.Short() {
+		steps = 50
+	}
+
+	for seed := int64(1); seed <= 5; seed++ {
+		f := newFixture(t).withTreasury(1000).build()
+		m := newBalanceModel(f.treasure(), f.options(DefaultCustomer).Registration)
+		r := rand.New(rand.NewSource(seed))
+
+		for step := 1; step <= steps; step++ {
+			function, args := randomOperation(r)
+			expected := m.apply(function, args)
+
+			response := f.invoke(function, args...)
+			assert(t, response.GetStatus() == expected, "seed %d step %d: %s%q returned %d (%s), model expects %d",
+				seed, step, function, args, response.GetStatus(), response.GetMessage(), expected)
+
+			checkModel(t, f, m, step)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+const DefaultProposalTTL = 7 * 24 * 60 * 60
+
+const (
+	ProposalStatusPending  = "pending"
+	ProposalStatusExecuted = "executed"
+)
+
+// TreasuryProposal is a mint, burn or withdraw that waits for approval by
+// ApprovalThreshold distinct admin organizations before it can be executed.
+type TreasuryProposal struct {
+	ObjectType     string             `json:"docType"`
+	SchemaVersion  int                `json:"schemaVersion"`
+	ID             string             `json:"id"`
+	Operation      string             `json:"operation"`
+	Amount         float64            `json:"amount"`
+	WalletID       string             `json:"walletId,omitempty"`
+	Action         string             `json:"action"`
+	ActionEntityID string             `json:"actionEntityId"`
+	Customer       string             `json:"customer"`
+	Proposer       string             `json:"proposer"`
+	RequiredMSPs   []string           `json:"requiredMSPs"`
+	Threshold      int                `json:"threshold"`
+	Approvals      []ProposalApproval `json:"approvals"`
+	Status         string             `json:"status"`
+	CreationDate   int64              `json:"creationDate"`
+	ExpiryDate     int64              `json:"expiryDate"`
+	ExecutionTxID  string             `json:"executionTxId,omitempty"`
+}
+
+type ProposalApproval struct {
+	MSPID        string `json:"mspId"`
+	ClientID     string `json:"clientId"`
+	ApprovalDate int64  `json:"approvalDate"`
+}
+
+func (s *SmartContract) proposeTreasuryOp(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 4 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 4"))
+	}
+
+	operation := args[0]
+	walletID := ""
+	switch operation {
+	case "mint", "burn":
+	case "withdraw":
+		if len(args) < 5 || args[4] == "" {
+			return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting a wallet id for withdraw"))
+		}
+		walletID = args[4]
+	default:
+		return errorResponse(newError(CodeInvalidArgument, "Invalid treasury operation "+operation+". Expecting mint, burn or withdraw"))
+	}
+
+	amount, err := parseSupplyAmount(args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	customer := DefaultCustomer
+	if len(args) >= 6 {
+		customer = args[5]
+	}
+
+	options, err := s.getOptionsObject(stub, DefaultCustomer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	var proposal = new(TreasuryProposal)
+	proposal.ObjectType = TreasuryProposalObjectType
+	proposal.SchemaVersion = schemaVersion(TreasuryProposalObjectType)
+	proposal.ID = stub.GetTxID()
+	proposal.Operation = operation
+	proposal.Amount = amount
+	proposal.WalletID = walletID
+	proposal.Action = args[2]
+	proposal.ActionEntityID = args[3]
+	proposal.Customer = customer
+	proposal.RequiredMSPs = options.AdminMSPs
+	proposal.Threshold = options.ApprovalThreshold
+	if proposal.Threshold <= 0 {
+		proposal.Threshold = len(proposal.RequiredMSPs)
+	}
+	proposal.Status = ProposalStatusPending
+	proposal.CreationDate = now
+
+	ttl := options.ProposalTTL
+	if ttl <= 0 {
+		ttl = DefaultProposalTTL
+	}
+	proposal.ExpiryDate = now + ttl
+
+	// The proposer implicitly approves its own proposal
+	err = addProposalApproval(stub, proposal, now)
+	if err != nil {
+		return errorResponse(err)
+	}
+	proposal.Proposer = proposal.Approvals[0].ClientID
+
+	asBytes, err := s.putProposal(stub, proposal)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+func (s *SmartContract) approveTreasuryOp(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	proposal, err := s.getPendingProposal(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = addProposalApproval(stub, proposal, now)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	asBytes, err := s.putProposal(stub, proposal)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+func (s *SmartContract) executeTreasuryOp(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	proposal, err := s.getPendingProposal(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	approved := countApprovedMSPs(proposal)
+	if approved < proposal.Threshold {
+		return errorResponse(newError(CodeForbidden, "Proposal "+proposal.ID+" has "+strconv.Itoa(approved)+" of "+strconv.Itoa(proposal.Threshold)+" required approvals"))
+	}
+
+	txID := stub.GetTxID()
+	switch proposal.Operation {
+	case "mint":
+		_, err = s.updateTreasureSupply(stub, proposal.Amount, "mint", txID, proposal.Action, proposal.ActionEntityID)
+	case "burn":
+		_, err = s.updateTreasureSupply(stub, -proposal.Amount, "burn", txID, proposal.Action, proposal.ActionEntityID)
+	case "withdraw":
+		err = s.updateTreasureBalance(stub, -proposal.Amount, "withdraw", txID, proposal.Action, proposal.ActionEntityID, proposal.Customer)
+		if err == nil {
+			err = s.updateWalletBalance(stub, proposal.Amount, proposal.WalletID, "withdraw", txID, proposal.Action, proposal.ActionEntityID, proposal.Customer)
+		}
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	proposal.Status = ProposalStatusExecuted
+	proposal.ExecutionTxID = txID
+
+	asBytes, err := s.putProposal(stub, proposal)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+func (s *SmartContract) getTreasuryOp(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	key, err := stub.CreateCompositeKey(TreasuryProposalObjectType, []string{args[0]})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	asBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	asBytes, _, err = upgradeDocument(stub, asBytes)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+// getPendingProposal loads a proposal that can still be approved or executed.
+func (s *SmartContract) getPendingProposal(stub shim.ChaincodeStubInterface, proposalID string) (*TreasuryProposal, error) {
+
+	key, err := stub.CreateCompositeKey(TreasuryProposalObjectType, []string{proposalID})
+	if err != nil {
+		return nil, err
+	}
+
+	asBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(asBytes) == 0 {
+		return nil, newError(CodeNotFound, "Proposal with id "+proposalID+" not found")
+	}
+
+	var proposal = new(TreasuryProposal)
+	err = unmarshalDocument(stub, asBytes, proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	if proposal.Status != ProposalStatusPending {
+		return nil, newError(CodeInvalidArgument, "Proposal with id "+proposalID+" is "+proposal.Status)
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	if now > proposal.ExpiryDate {
+		return nil, newError(CodeInvalidArgument, "Proposal with id "+proposalID+" has expired")
+	}
+
+	return proposal, nil
+}
+
+func (s *SmartContract) putProposal(stub shim.ChaincodeStubInterface, proposal *TreasuryProposal) ([]byte, error) {
+
+	key, err := stub.CreateCompositeKey(TreasuryProposalObjectType, []string{proposal.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	asBytes, err := json.Marshal(proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stub.PutState(key, asBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return asBytes, nil
+}
+
+// addProposalApproval records the invoker's approval. Every client identity
+// approves at most once and must belong to one of the required MSPs.
+func addProposalApproval(stub shim.ChaincodeStubInterface, proposal *TreasuryProposal, now int64) error {
+
+	mspID, clientID, err := getInvoker(stub)
+	if err != nil {
+		return err
+	}
+
+	if !containsString(proposal.RequiredMSPs, mspID) {
+		return errNotAdmin
+	}
+
+	for _, approval := range proposal.Approvals {
+		if approval.ClientID == clientID {
+			return newError(CodeAlreadyExists, "Proposal with id "+proposal.ID+" is already approved by the invoker")
+		}
+	}
+
+	proposal.Approvals = append(proposal.Approvals, ProposalApproval{
+		MSPID:        mspID,
+		ClientID:     clientID,
+		ApprovalDate: now,
+	})
+	return nil
+}
+
+// countApprovedMSPs returns the number of distinct organizations that approved.
+func countApprovedMSPs(proposal *TreasuryProposal) int {
+
+	var msps []string
+	for _, approval := range proposal.Approvals {
+		if !containsString(msps, approval.MSPID) {
+			msps = append(msps, approval.MSPID)
+		}
+	}
+	return len(msps)
+}
+
+func getTxTime(stub shim.ChaincodeStubInterface) (int64, error) {
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, err
+	}
+	return timestamp.GetSeconds(), nil
+}
@@ -0,0 +1,193 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestTreasuryProposal(t *testing.T) {
+	t.Log("Test proposeTreasuryOp, approveTreasuryOp and executeTreasuryOp")
+	mockStub := shim.NewMockStub("proposal", new(SmartContract))
+	admin1 := newInvoker(t, mockStub, "Org1MSP", "admin1")
+	admin2 := newInvoker(t, mockStub, "Org2MSP", "admin2")
+
+	response := admin1.MockInit("1", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+
+	response = admin1.MockInvoke("2", [][]byte{[]byte("setOptions"),
+		[]byte(`{"registration":110,"approvalLimit":1000,"approvalThreshold":2,"adminMSPs":["Org1MSP","Org2MSP"]}`)})
+	equals(t, int32(200), response.GetStatus())
+
+	response = admin1.MockInvoke("3", [][]byte{[]byte("proposeTreasuryOp"),
+		[]byte("mint"), []byte("5000"), []byte("MINT"), []byte("MINT_NUMBER_1")})
+	equals(t, int32(200), response.GetStatus())
+
+	var proposal = new(TreasuryProposal)
+	err := json.Unmarshal(response.GetPayload(), proposal)
+	ok(t, err)
+	equals(t, "3", proposal.ID)
+	equals(t, 2, proposal.Threshold)
+	equals(t, 1, len(proposal.Approvals))
+
+	response = admin2.MockInvoke("4", [][]byte{[]byte("approveTreasuryOp"), []byte(proposal.ID)})
+	equals(t, int32(200), response.GetStatus())
+
+	response = admin2.MockInvoke("5", [][]byte{[]byte("executeTreasuryOp"), []byte(proposal.ID)})
+	equals(t, int32(200), response.GetStatus())
+
+	err = json.Unmarshal(response.GetPayload(), proposal)
+	ok(t, err)
+	equals(t, ProposalStatusExecuted, proposal.Status)
+
+	response = admin1.MockInvoke("6", [][]byte{[]byte("getTreasure")})
+	equals(t, int32(200), response.GetStatus())
+
+	var treasure = new(Treasure)
+	err = json.Unmarshal(response.GetPayload(), treasure)
+	ok(t, err)
+	equals(t, float64(210005000), treasure.Balance)
+	equals(t, float64(210005000), treasure.TotalSupply)
+}
+
+func TestTreasuryWithdrawal(t *testing.T) {
+	t.Log("Test payouts to wallets above the approval limit need a withdraw proposal")
+	f := newFixture(t).withAdmins("Org1MSP", "Org2MSP").
+		withOptions(Options{Customer: DefaultCustomer, Registration: 110, ApprovalLimit: 1000, ApprovalThreshold: 2}).
+		withWallet("w1", "hash1", 1000).build()
+
+	response := f.invoke("purchaseCoins", "w1", "1000", "order", "1")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("proposeTreasuryOp", "withdraw", "5000", "WITHDRAW", "WITHDRAW_NUMBER_1", "w1")
+	equals(t, int32(200), response.GetStatus())
+
+	var proposal = new(TreasuryProposal)
+	err := json.Unmarshal(response.GetPayload(), proposal)
+	ok(t, err)
+
+	response = f.invokeAs(f.as("Org2MSP", "admin2"), "approveTreasuryOp", proposal.ID)
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("executeTreasuryOp", proposal.ID)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, float64(7000), f.wallet("w1").Amount)
+
+	// ---- Negative Cases ----
+	response = f.invoke("purchaseCoins", "w1", "1001", "order", "2")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invoke("createWallet", "w2", "hash2", "5000", "registration", "w2")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+	equals(t, float64(7000), f.wallet("w1").Amount)
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestTreasuryProposalNegative(t *testing.T) {
+	t.Log("Test treasury proposals Negative")
+	mockStub := shim.NewMockStub("proposal", new(SmartContract))
+	admin1 := newInvoker(t, mockStub, "Org1MSP", "admin1")
+	admin2 := newInvoker(t, mockStub, "Org2MSP", "admin2")
+	outsider := newInvoker(t, mockStub, "Org3MSP", "user1")
+
+	response := admin1.MockInit("1", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+
+	response = admin1.MockInvoke("2", [][]byte{[]byte("setOptions"),
+		[]byte(`{"registration":110,"approvalLimit":1000,"approvalThreshold":2,"adminMSPs":["Org1MSP","Org2MSP"]}`)})
+	equals(t, int32(200), response.GetStatus())
+
+	// Above the approval limit and outside the admin organizations
+	response = admin1.MockInvoke("3", [][]byte{[]byte("mintCoins"),
+		[]byte("5000"), []byte("MINT"), []byte("MINT_NUMBER_1")})
+	equals(t, int32(403), response.GetStatus())
+
+	response = outsider.MockInvoke("4", [][]byte{[]byte("mintCoins"),
+		[]byte("10"), []byte("MINT"), []byte("MINT_NUMBER_2")})
+	equals(t, int32(403), response.GetStatus())
+
+	response = outsider.MockInvoke("5", [][]byte{[]byte("proposeTreasuryOp"),
+		[]byte("mint"), []byte("5000"), []byte("MINT"), []byte("MINT_NUMBER_3")})
+	equals(t, int32(403), response.GetStatus())
+
+	response = admin1.MockInvoke("6", [][]byte{[]byte("proposeTreasuryOp"),
+		[]byte("withdraw"), []byte("5000"), []byte("WITHDRAW"), []byte("WITHDRAW_NUMBER_1")})
+	equals(t, int32(400), response.GetStatus())
+
+	response = admin1.MockInvoke("7", [][]byte{[]byte("proposeTreasuryOp"),
+		[]byte("burn"), []byte("5000"), []byte("BURN"), []byte("BURN_NUMBER_1")})
+	equals(t, int32(200), response.GetStatus())
+
+	// Threshold not met, double approval and approval from another organization
+	response = admin1.MockInvoke("8", [][]byte{[]byte("executeTreasuryOp"), []byte("7")})
+	equals(t, int32(403), response.GetStatus())
+
+	response = admin1.MockInvoke("9", [][]byte{[]byte("approveTreasuryOp"), []byte("7")})
+	equals(t, int32(412), response.GetStatus())
+
+	response = outsider.MockInvoke("10", [][]byte{[]byte("approveTreasuryOp"), []byte("7")})
+	equals(t, int32(403), response.GetStatus())
+
+	response = admin2.MockInvoke("11", [][]byte{[]byte("approveTreasuryOp"), []byte("7")})
+	equals(t, int32(200), response.GetStatus())
+
+	response = admin2.MockInvoke("12", [][]byte{[]byte("executeTreasuryOp"), []byte("7")})
+	equals(t, int32(200), response.GetStatus())
+
+	// Executed proposals cannot be replayed
+	response = admin2.MockInvoke("13", [][]byte{[]byte("executeTreasuryOp"), []byte("7")})
+	equals(t, int32(400), response.GetStatus())
+
+	response = admin2.MockInvoke("14", [][]byte{[]byte("approveTreasuryOp"), []byte("unknown")})
+	equals(t, int32(404), response.GetStatus())
+
+	// A threshold no set of admin organizations can reach
+	response = admin1.MockInvoke("15", [][]byte{[]byte("setOptions"),
+		[]byte(`{"registration":110,"approvalThreshold":3}`)})
+	equals(t, int32(400), response.GetStatus())
+
+	response = admin1.MockInvoke("16", [][]byte{[]byte("setOptions"),
+		[]byte(`{"registration":110,"approvalThreshold":-1}`)})
+	equals(t, int32(400), response.GetStatus())
+}
+
+func TestTreasuryProposalExpiry(t *testing.T) {
+	t.Log("Test expired treasury proposals cannot be approved or executed")
+	mockStub := shim.NewMockStub("proposal", new(SmartContract))
+	admin1 := newInvoker(t, mockStub, "Org1MSP", "admin1")
+	admin2 := newInvoker(t, mockStub, "Org2MSP", "admin2")
+
+	response := admin1.MockInit("1", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+
+	response = admin1.MockInvoke("2", [][]byte{[]byte("setOptions"),
+		[]byte(`{"registration":110,"approvalLimit":1000,"approvalThreshold":2,"adminMSPs":["Org1MSP","Org2MSP"],"proposalTTL":60}`)})
+	equals(t, int32(200), response.GetStatus())
+
+	response = admin1.MockInvoke("3", [][]byte{[]byte("proposeTreasuryOp"),
+		[]byte("mint"), []byte("5000"), []byte("MINT"), []byte("MINT_NUMBER_1")})
+	equals(t, int32(200), response.GetStatus())
+
+	var proposal = new(TreasuryProposal)
+	err := json.Unmarshal(response.GetPayload(), proposal)
+	ok(t, err)
+	equals(t, proposal.CreationDate+60, proposal.ExpiryDate)
+
+	// Move the expiry date into the past, as if the TTL had elapsed
+	key, err := mockStub.CreateCompositeKey(TreasuryProposalObjectType, []string{proposal.ID})
+	ok(t, err)
+	proposal.ExpiryDate = proposal.CreationDate - 1
+	asBytes, err := json.Marshal(proposal)
+	ok(t, err)
+	mockStub.MockTransactionStart("expire")
+	ok(t, mockStub.PutState(key, asBytes))
+	mockStub.MockTransactionEnd("expire")
+
+	response = admin2.MockInvoke("4", [][]byte{[]byte("approveTreasuryOp"), []byte(proposal.ID)})
+	equals(t, int32(400), response.GetStatus())
+
+	response = admin2.MockInvoke("5", [][]byte{[]byte("executeTreasuryOp"), []byte(proposal.ID)})
+	equals(t, int32(400), response.GetStatus())
+}
@@ -0,0 +1,317 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+func (s *SmartContract) searchWallets(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	return s.searchEntities(stub, WalletObjectType, args)
+}
+
+func (s *SmartContract) searchWalletTransactions(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	return s.searchEntities(stub, WalletTransactionObjectType, args)
+}
+
+func (s *SmartContract) searchTreasureTransactions(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	return s.searchEntities(stub, TreasureTransactionObjectType, args)
+}
+
+// searchIndex mirrors an index definition in META-INF/statedb/couchdb/indexes.
+type searchIndex struct {
+	DesignDoc string
+	Name      string
+	Fields    []string
+}
+
+// searchIndexes are tried in order; a query uses the first index whose fields
+// are all part of its selector.
+var searchIndexes = []searchIndex{
+	{"indexWalletIdDoc", "indexWalletId", []string{"docType", "walletId", "creationDate"}},
+	{"indexCustomerDoc", "indexCustomer", []string{"docType", "customer", "creationDate"}},
+	{"indexActionDoc", "indexAction", []string{"docType", "action"}},
+	{"indexDocTypeDoc", "indexDocType", []string{"docType"}},
+}
+
+// searchFields lists the fields of each docType that the searchIndexes cover
+// and that searches without rich queries can filter on. Wallets are not
+// searched by mobileHash, a salted commitment: getWalletByMobileHash looks
+// them up in the mobile index.
+var searchFields = map[string][]string{
+	WalletTransactionObjectType:   {"walletId", "customer", "action"},
+	TreasureTransactionObjectType: {"customer", "action"},
+}
+
+// sortedDocTypes are sorted by creationDate, newest first, when the index
+// allows it.
+var sortedDocTypes = []string{WalletTransactionObjectType, TreasureTransactionObjectType}
+
+type searchQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []map[string]string    `json:"sort,omitempty"`
+	UseIndex []string               `json:"use_index,omitempty"`
+	Limit    int                    `json:"limit"`
+	Skip     int                    `json:"skip"`
+
+	// filter is the filter of the caller the selector was built from
+	filter map[string]interface{}
+}
+
+func (s *SmartContract) searchEntities(stub shim.ChaincodeStubInterface, DocType string, args []string) sc.Response {
+
+	filter := map[string]interface{}{}
+	if len(args) > 0 && args[0] != "" {
+		var err error
+		filter, err = parseSearchFilter(args[0])
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+	limit := 10
+	skip := 0
+	if len(args) >= 3 {
+		page, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errorResponse(newError(CodeInvalidArgument, err.Error()))
+		}
+
+		size, err := strconv.Atoi(args[2])
+		if err != nil {
+			return errorResponse(newError(CodeInvalidArgument, err.Error()))
+		}
+
+		skip = (page - 1) * size
+		limit = size
+	}
+
+	searchQuery, err := buildSearchQuery(DocType, filter, limit, skip)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	queryBytes, err := json.Marshal(searchQuery)
+	if err != nil {
+		return errorResponse(err)
+	}
+	query := string(queryBytes)
+
+	switch s.stateDatabase(stub) {
+	case StateDatabaseLevelDB:
+		return s.searchByCompositeKey(stub, DocType, searchQuery)
+	case StateDatabaseCouchDB:
+		return s.queryData(stub, query)
+	}
+
+	// if len(args) >= 2 {
+	// 	pageSize, err := strconv.ParseInt(args[1], 10, 32)
+	// 	if err != nil {
+	// 		return shim.Error(err.Error())
+	// 	}
+
+	// 	bookmark := ""
+	// 	if len(args) >= 3 {
+	// 		bookmark = args[2]
+	// 	}
+
+	// 	return s.queryDataWithBookmark(stub, query, int32(pageSize), bookmark)
+	// }
+
+	results, err := richQuery(stub, query)
+	if isRichQueryUnsupported(err) {
+		logger.Warning("Rich queries are not supported by the state database, falling back to a composite key search")
+		return s.searchByCompositeKey(stub, DocType, searchQuery)
+	}
+
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(results)
+}
+
+// levelDBQueryUnsupported is part of the error of a peer with a LevelDB state
+// database for a rich query.
+const levelDBQueryUnsupported = "not supported for leveldb"
+
+// isRichQueryUnsupported reports whether err is the error of a state database
+// without rich queries. Other errors, such as a CouchDB timeout, must not
+// turn into a full composite key scan.
+func isRichQueryUnsupported(err error) bool {
+	return err != nil && strings.Contains(err.Error(), levelDBQueryUnsupported)
+}
+
+// parseSearchFilter accepts either a JSON object or the legacy selector
+// fragment such as "walletId":"abc".
+func parseSearchFilter(arg string) (map[string]interface{}, error) {
+
+	arg = strings.TrimSpace(arg)
+	if !strings.HasPrefix(arg, "{") {
+		arg = "{" + arg + "}"
+	}
+
+	var filter map[string]interface{}
+	err := json.Unmarshal([]byte(arg), &filter)
+	if err != nil {
+		return nil, newError(CodeInvalidArgument, "Invalid search filter: "+err.Error())
+	}
+
+	return filter, nil
+}
+
+// buildSearchQuery builds a CouchDB query of docType from a Mango selector
+// filter, which may use any field and operator. It uses the first of the
+// searchIndexes that covers the selector; equality filters on the
+// searchFields of docType are always covered.
+func buildSearchQuery(docType string, filter map[string]interface{}, limit, skip int) (*searchQuery, error) {
+
+	if _, found := filter["docType"]; found {
+		return nil, newError(CodeInvalidArgument, "Field docType is not searchable on "+docType)
+	}
+
+	if _, found := filter["mobileHash"]; found && docType == WalletObjectType {
+		return nil, newError(CodeInvalidArgument, "Field mobileHash is not searchable on "+docType+", see getWalletByMobileHash")
+	}
+
+	var query = new(searchQuery)
+	query.Selector = map[string]interface{}{"docType": docType}
+	query.Limit = limit
+	query.Skip = skip
+	query.filter = filter
+
+	for field, value := range filter {
+		query.Selector[field] = value
+	}
+
+	_, filtersCreationDate := filter["creationDate"]
+	if containsString(sortedDocTypes, docType) && !filtersCreationDate {
+		query.Selector["creationDate"] = map[string]interface{}{"$gt": nil}
+	}
+
+	for _, index := range searchIndexes {
+		if !indexCoversSelector(index, query.Selector) {
+			continue
+		}
+
+		query.UseIndex = []string{"_design/" + index.DesignDoc, index.Name}
+		if index.Fields[len(index.Fields)-1] == "creationDate" {
+			for _, field := range index.Fields {
+				query.Sort = append(query.Sort, map[string]string{field: "desc"})
+			}
+		}
+		break
+	}
+
+	if !filtersCreationDate && query.Sort == nil {
+		delete(query.Selector, "creationDate")
+	}
+
+	return query, nil
+}
+
+func indexCoversSelector(index searchIndex, selector map[string]interface{}) bool {
+	for _, field := range index.Fields {
+		if _, found := selector[field]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *SmartContract) queryData(stub shim.ChaincodeStubInterface, query string) sc.Response {
+
+	results, err := richQuery(stub, query)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(results)
+}
+
+// richQuery returns the documents selected by a CouchDB query as a JSON array.
+func richQuery(stub shim.ChaincodeStubInterface, query string) ([]byte, error) {
+
+	resultsIterator, err := stub.GetQueryResult(query)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	buffer, err := constructQueryResponseFromIterator(stub, resultsIterator)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// To be include only in version 1.4
+// func (s *SmartContract) queryDataWithBookmark(stub shim.ChaincodeStubInterface, query string, pageSize int32, bookmark string) sc.Response {
+
+// 	fmt.Printf(" query:%s\n", query)
+// 	resultsIterator, responseMetadata, err := stub.GetQueryResultWithPagination(query, pageSize, bookmark)
+// 	if err != nil {
+// 		return shim.Error(err.Error())
+// 	}
+// 	defer resultsIterator.Close()
+
+// 	buffer, err := constructQueryResponseFromIterator(resultsIterator)
+// 	if err != nil {
+// 		return shim.Error(err.Error())
+// 	}
+
+// 	bufferWithPaginationInfo := addPaginationMetadataToQueryResults(buffer, responseMetadata)
+
+// 	fmt.Printf("- queryData:\n%s\n", bufferWithPaginationInfo.String())
+// 	return shim.Success(bufferWithPaginationInfo.Bytes())
+// }
+
+func constructQueryResponseFromIterator(stub shim.ChaincodeStubInterface, resultsIterator shim.StateQueryIteratorInterface) (*bytes.Buffer, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("[")
+
+	bArrayMemberAlreadyWritten := false
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		// Add a comma before array members, suppress it for the first array member
+		if bArrayMemberAlreadyWritten == true {
+			buffer.WriteString(",")
+		}
+		// Record is a JSON object, so we write it as-is once it is migrated
+		value, _, err := upgradeDocument(stub, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.WriteString(string(value))
+
+		bArrayMemberAlreadyWritten = true
+	}
+
+	buffer.WriteString("]")
+	return &buffer, nil
+}
+
+// To be include only in version 1.4
+// func addPaginationMetadataToQueryResults(buffer *bytes.Buffer, responseMetadata *sc.QueryResponseMetadata) *bytes.Buffer {
+
+// 	buffer.WriteString("[{\"ResponseMetadata\":{\"RecordsCount\":")
+// 	buffer.WriteString("\"")
+// 	buffer.WriteString(fmt.Sprintf("%v", responseMetadata.FetchedRecordsCount))
+// 	buffer.WriteString("\"")
+// 	buffer.WriteString(", \"Bookmark\":")
+// 	buffer.WriteString("\"")
+// 	buffer.WriteString(responseMetadata.Bookmark)
+// 	buffer.WriteString("\"}}]")
+
+// 	return buffer
+// }
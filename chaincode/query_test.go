@@ -0,0 +1,222 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The indexes are packaged with the chaincode binary in the repository root.
+var couchDBIndexPath = filepath.Join("..", "META-INF", "statedb", "couchdb", "indexes")
+
+type couchDBIndex struct {
+	Index struct {
+		Fields []string `json:"fields"`
+	} `json:"index"`
+	DesignDoc string `json:"ddoc"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+}
+
+func readCouchDBIndexes(t *testing.T) map[string]couchDBIndex {
+	files, err := filepath.Glob(filepath.Join(couchDBIndexPath, "*.json"))
+	ok(t, err)
+
+	indexes := map[string]couchDBIndex{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		ok(t, err)
+
+		var index couchDBIndex
+		err = json.Unmarshal(content, &index)
+		ok(t, err)
+		indexes["_design/"+index.DesignDoc+"/"+index.Name] = index
+	}
+	return indexes
+}
+
+func TestSearchIndexDefinitions(t *testing.T) {
+	t.Log("Test searchIndexes match the CouchDB index definitions")
+	indexes := readCouchDBIndexes(t)
+	equals(t, len(searchIndexes), len(indexes))
+
+	for _, searchIndex := range searchIndexes {
+		index, found := indexes["_design/"+searchIndex.DesignDoc+"/"+searchIndex.Name]
+		assert(t, found, "no index definition for %s", searchIndex.Name)
+		equals(t, "json", index.Type)
+		equals(t, searchIndex.Fields, index.Index.Fields)
+	}
+}
+
+func TestSearchQueriesAreIndexed(t *testing.T) {
+	t.Log("Test every query shape of buildSearchQuery is covered by an index")
+	indexes := readCouchDBIndexes(t)
+
+	for docType, fields := range searchFields {
+		// Every combination of the searchable fields of the docType
+		for combination := 0; combination < 1<<uint(len(fields)); combination++ {
+			filter := map[string]interface{}{}
+			for i, field := range fields {
+				if combination&(1<<uint(i)) != 0 {
+					filter[field] = "value"
+				}
+			}
+
+			query, err := buildSearchQuery(docType, filter, 10, 0)
+			ok(t, err)
+			assert(t, len(query.UseIndex) == 2, "no index for %s %v", docType, filter)
+
+			index, found := indexes[query.UseIndex[0]+"/"+query.UseIndex[1]]
+			assert(t, found, "unknown index %v for %s %v", query.UseIndex, docType, filter)
+			for _, field := range index.Index.Fields {
+				_, found := query.Selector[field]
+				assert(t, found, "index %s needs %s in the selector of %s %v", index.Name, field, docType, filter)
+			}
+			for i, sort := range query.Sort {
+				_, found := sort[index.Index.Fields[i]]
+				assert(t, found, "sort %v of %s %v does not follow index %s", query.Sort, docType, filter, index.Name)
+			}
+		}
+	}
+}
+
+func TestParseSearchFilter(t *testing.T) {
+	t.Log("Test parseSearchFilter")
+	tests := []string{
+		`"walletId":"abc"`,
+		`{"walletId":"abc"}`,
+	}
+
+	for _, test := range tests {
+		filter, err := parseSearchFilter(test)
+		ok(t, err)
+		equals(t, map[string]interface{}{"walletId": "abc"}, filter)
+	}
+}
+
+func newSearchFixture(t *testing.T) *fixture {
+	f := newFixture(t).build()
+	for _, walletID := range []string{"wallet_a", "wallet_b"} {
+		response := f.invoke("createWallet", walletID, "hash_"+walletID, "", DefaultAction, walletID, "customer_"+walletID)
+		equals(t, int32(200), response.GetStatus())
+	}
+
+	for i, walletID := range []string{"wallet_a", "wallet_a", "wallet_b"} {
+		response := f.invoke("purchaseCoins", walletID, "10", "MAGIC_BOX", "BOX_NUMBER_"+strconv.Itoa(i))
+		equals(t, int32(200), response.GetStatus())
+	}
+
+	response := f.invoke("spendCoins", "wallet_b", "5", "PREDICTION", "P_NUMBER_1")
+	equals(t, int32(200), response.GetStatus())
+	return f
+}
+
+func TestSearchWallets(t *testing.T) {
+	t.Log("Test searchWallets")
+	f := newSearchFixture(t)
+
+	tests := []struct {
+		args []string
+		ids  []string
+	}{
+		{[]string{""}, []string{"wallet_a", "wallet_b"}},
+		{[]string{`"id":"wallet_b"`}, []string{"wallet_b"}},
+		{[]string{`{"id":"unknown"}`}, nil},
+		{[]string{"", "2", "1"}, []string{"wallet_b"}},
+		// Operators and other fields are passed to CouchDB
+		{[]string{`{"amount":{"$gt":120}}`}, []string{"wallet_a"}},
+		{[]string{`{"id":{"$in":["wallet_a","unknown"]}}`}, []string{"wallet_a"}},
+	}
+
+	for _, test := range tests {
+		response := f.invoke("searchWallets", test.args...)
+		equals(t, int32(200), response.GetStatus())
+
+		var wallets []Wallet
+		err := json.Unmarshal(response.GetPayload(), &wallets)
+		ok(t, err)
+
+		var ids []string
+		for _, wallet := range wallets {
+			ids = append(ids, wallet.ID)
+		}
+		equals(t, test.ids, ids)
+	}
+}
+
+func TestSearchWalletTransactions(t *testing.T) {
+	t.Log("Test searchWalletTransactions")
+	f := newSearchFixture(t)
+
+	tests := []struct {
+		filter   string
+		entities []string
+	}{
+		{`"walletId":"wallet_a"`, []string{"BOX_NUMBER_0", "BOX_NUMBER_1", "wallet_a"}},
+		{`{"walletId":"wallet_b","action":"PREDICTION"}`, []string{"P_NUMBER_1"}},
+		{`{"customer":"customer_wallet_b"}`, []string{"wallet_b"}},
+		{`{"action":"MAGIC_BOX"}`, []string{"BOX_NUMBER_0", "BOX_NUMBER_1", "BOX_NUMBER_2"}},
+	}
+
+	for _, test := range tests {
+		response := f.invoke("searchWalletTransactions", test.filter)
+		equals(t, int32(200), response.GetStatus())
+
+		var transactions []WalletTransaction
+		err := json.Unmarshal(response.GetPayload(), &transactions)
+		ok(t, err)
+
+		var entities []string
+		for i, transaction := range transactions {
+			entities = append(entities, transaction.ActionEntityID)
+			if i > 0 && !strings.Contains(test.filter, "action") {
+				assert(t, transaction.CreationDate <= transactions[i-1].CreationDate, "%s is not sorted by creationDate", test.filter)
+			}
+		}
+		sort.Strings(entities)
+		equals(t, test.entities, entities)
+	}
+}
+
+func TestSearchTreasureTransactions(t *testing.T) {
+	t.Log("Test searchTreasureTransactions")
+	f := newSearchFixture(t)
+
+	tests := []struct {
+		filter string
+		count  int
+	}{
+		{"", 7},
+		{`"action":"MAGIC_BOX"`, 3},
+		{`{"customer":"customer_wallet_a"}`, 1},
+	}
+
+	for _, test := range tests {
+		response := f.invoke("searchTreasureTransactions", test.filter)
+		equals(t, int32(200), response.GetStatus())
+
+		var transactions []TreasureTransaction
+		err := json.Unmarshal(response.GetPayload(), &transactions)
+		ok(t, err)
+		equals(t, test.count, len(transactions))
+	}
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestBuildSearchQueryNegative(t *testing.T) {
+	t.Log("Test buildSearchQuery Negative")
+	tests := []map[string]interface{}{
+		{"docType": OptionsObjectType},
+		{"docType": map[string]interface{}{"$ne": WalletTransactionObjectType}},
+	}
+
+	for _, filter := range tests {
+		_, err := buildSearchQuery(WalletTransactionObjectType, filter, 10, 0)
+		assert(t, err != nil, "expected an error for %v", filter)
+	}
+}
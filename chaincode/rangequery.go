@@ -0,0 +1,164 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+const (
+	StateDatabaseCouchDB = "couchdb"
+	StateDatabaseLevelDB = "leveldb"
+)
+
+// compositeKeyFields lists the attributes of the composite key of each
+// searchable docType, in key order.
+var compositeKeyFields = map[string][]string{
+	WalletObjectType:              {"id"},
+	WalletTransactionObjectType:   {"walletId", "action", "actionEntityId"},
+	TreasureTransactionObjectType: {"txId", "type"},
+}
+
+// maxSortedResults caps the documents a search without rich queries holds in
+// memory to sort them. Without a sort the scan stops after the page instead.
+var maxSortedResults = 1000
+
+type rangeSearchResult struct {
+	creationDate float64
+	value        []byte
+}
+
+// stateDatabase returns the stateDatabase option of the default customer.
+// An empty value means the state database is detected per query.
+func (s *SmartContract) stateDatabase(stub shim.ChaincodeStubInterface) string {
+
+	options, err := s.getOptionsObject(stub, DefaultCustomer)
+	if err != nil {
+		return ""
+	}
+	return options.StateDatabase
+}
+
+// searchByCompositeKey answers a search query without rich query support, as
+// on LevelDB. It narrows the scan with the longest composite key prefix the
+// filter allows and applies the rest of the selector, the sort and the paging
+// in chaincode.
+func (s *SmartContract) searchByCompositeKey(stub shim.ChaincodeStubInterface, docType string, query *searchQuery) sc.Response {
+
+	err := checkCompositeKeyFilter(docType, query.filter)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	var prefix []string
+	for _, field := range compositeKeyFields[docType] {
+		value, found := query.Selector[field].(string)
+		if !found {
+			break
+		}
+		prefix = append(prefix, value)
+	}
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(docType, prefix)
+	if err != nil {
+		return errorResponse(err)
+	}
+	defer resultsIterator.Close()
+
+	var results []rangeSearchResult
+	for resultsIterator.HasNext() {
+		// Without a sort the scan can stop once the requested page is complete
+		if query.Sort == nil && len(results) >= query.Skip+query.Limit {
+			break
+		}
+
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		value, _, err := upgradeDocument(stub, queryResponse.Value)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		var doc map[string]interface{}
+		err = json.Unmarshal(value, &doc)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if !matchesSelector(doc, query.Selector) {
+			continue
+		}
+
+		if query.Sort != nil && len(results) == maxSortedResults {
+			return errorResponse(newError(CodeInvalidArgument, "Too many documents to sort without CouchDB. Expecting a filter that matches at most "+strconv.Itoa(maxSortedResults)))
+		}
+
+		creationDate, _ := doc["creationDate"].(float64)
+		results = append(results, rangeSearchResult{creationDate, value})
+	}
+
+	if query.Sort != nil {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].creationDate > results[j].creationDate
+		})
+	}
+
+	start := query.Skip
+	if start < 0 {
+		start = 0
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("[")
+	for i := start; i < len(results) && i < query.Skip+query.Limit; i++ {
+		if i > start {
+			buffer.WriteString(",")
+		}
+		buffer.Write(results[i].value)
+	}
+	buffer.WriteString("]")
+
+	return shim.Success(buffer.Bytes())
+}
+
+// checkCompositeKeyFilter rejects the filters a search without rich queries
+// cannot answer: it only compares the searchFields of docType for equality.
+func checkCompositeKeyFilter(docType string, filter map[string]interface{}) error {
+
+	for field, value := range filter {
+		if !containsString(searchFields[docType], field) {
+			return newError(CodeInvalidArgument, "Field "+field+" is not searchable on "+docType+" without CouchDB")
+		}
+
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			return newError(CodeInvalidArgument, "Field "+field+" only supports a string, number or boolean value without CouchDB")
+		}
+	}
+	return nil
+}
+
+// matchesSelector checks the equality conditions of a selector built by
+// buildSearchQuery. Operator conditions such as the creationDate sort guard
+// match every document.
+func matchesSelector(doc map[string]interface{}, selector map[string]interface{}) bool {
+
+	for field, expected := range selector {
+		if _, isOperator := expected.(map[string]interface{}); isOperator {
+			continue
+		}
+
+		if doc[field] != expected {
+			return false
+		}
+	}
+	return true
+}
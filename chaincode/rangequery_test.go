@@ -0,0 +1,134 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// errLevelDBQuery is the error of a LevelDB peer for a rich query.
+var errLevelDBQuery = errors.New("GET_QUERY_RESULT failed: transaction ID: 4: ExecuteQuery not supported for leveldb")
+
+// newRangeQueryStub returns a stub whose rich queries fail like on LevelDB.
+func newRangeQueryStub(t *testing.T) *invokerStub {
+	mockStub := newInvoker(t, shim.NewMockStub("rangequery", new(SmartContract)), "Org1MSP", "admin")
+	response := mockStub.MockInit("1", [][]byte{[]byte("init")})
+	equals(t, int32(200), response.GetStatus())
+
+	for _, walletID := range []string{"wallet_a", "wallet_b"} {
+		response = mockStub.MockInvoke("create_"+walletID, [][]byte{[]byte("createWallet"),
+			[]byte(walletID), []byte("hash_" + walletID)})
+		equals(t, int32(200), response.GetStatus())
+	}
+
+	for _, box := range []string{"BOX_NUMBER_1", "BOX_NUMBER_2"} {
+		response = mockStub.MockInvoke("purchase_"+box, [][]byte{[]byte("purchaseCoins"),
+			[]byte("wallet_a"), []byte("10"), []byte("MAGIC_BOX"), []byte(box)})
+		equals(t, int32(200), response.GetStatus())
+	}
+
+	mockStub.queryErr = errLevelDBQuery
+	return mockStub
+}
+
+func TestSearchByCompositeKey(t *testing.T) {
+	t.Log("Test searches without rich query support")
+	mockStub := newRangeQueryStub(t)
+
+	tests := []struct {
+		function string
+		filter   string
+		count    int
+	}{
+		{"searchWallets", "", 2},
+		{"searchWalletTransactions", `"walletId":"wallet_a"`, 3},
+		{"searchWalletTransactions", `{"walletId":"wallet_a","action":"MAGIC_BOX"}`, 2},
+		{"searchWalletTransactions", `{"action":"MAGIC_BOX"}`, 2},
+		{"searchWalletTransactions", `{"customer":"unknown"}`, 0},
+		{"searchTreasureTransactions", "", 5},
+	}
+
+	for _, test := range tests {
+		response := mockStub.MockInvoke("4", [][]byte{[]byte(test.function), []byte(test.filter)})
+		equals(t, int32(200), response.GetStatus())
+
+		var results []map[string]interface{}
+		err := json.Unmarshal(response.GetPayload(), &results)
+		ok(t, err)
+		assert(t, len(results) == test.count, "%s %s: expected %d results, got %d", test.function, test.filter, test.count, len(results))
+	}
+}
+
+func TestSearchByCompositeKeyPaging(t *testing.T) {
+	t.Log("Test paging of searches without rich query support")
+	mockStub := newRangeQueryStub(t)
+	response := mockStub.MockInvoke("4", [][]byte{[]byte("setOptions"),
+		[]byte(`{"registration":110,"stateDatabase":"` + StateDatabaseLevelDB + `"}`)})
+	equals(t, int32(200), response.GetStatus())
+
+	var seen []string
+	for page := 1; page <= 3; page++ {
+		response = mockStub.MockInvoke("5", [][]byte{[]byte("searchWalletTransactions"),
+			[]byte(`"walletId":"wallet_a"`), []byte(strconv.Itoa(page)), []byte("1")})
+		equals(t, int32(200), response.GetStatus())
+
+		var results []WalletTransaction
+		err := json.Unmarshal(response.GetPayload(), &results)
+		ok(t, err)
+		equals(t, 1, len(results))
+		seen = append(seen, results[0].ActionEntityID)
+	}
+	equals(t, 3, len(seen))
+	assert(t, seen[0] != seen[1] && seen[1] != seen[2] && seen[0] != seen[2], "pages overlap: %v", seen)
+}
+
+func TestSearchByCompositeKeySortLimit(t *testing.T) {
+	t.Log("Test searches without rich query support only sort a limited number of documents")
+	mockStub := newRangeQueryStub(t)
+	defer func(limit int) { maxSortedResults = limit }(maxSortedResults)
+	maxSortedResults = 3
+
+	// wallet_a has three transactions, sorted by creationDate
+	response := mockStub.MockInvoke("4", [][]byte{[]byte("searchWalletTransactions"), []byte(`"walletId":"wallet_a"`)})
+	equals(t, int32(200), response.GetStatus())
+
+	maxSortedResults = 2
+	response = mockStub.MockInvoke("5", [][]byte{[]byte("searchWalletTransactions"), []byte(`"walletId":"wallet_a"`)})
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	// Unsorted searches stop after the page instead
+	response = mockStub.MockInvoke("6", [][]byte{[]byte("searchWalletTransactions"), []byte(`"action":"MAGIC_BOX"`)})
+	equals(t, int32(200), response.GetStatus())
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestSearchByCompositeKeyNegative(t *testing.T) {
+	t.Log("Test searches without rich query support Negative")
+	mockStub := newRangeQueryStub(t)
+	response := mockStub.MockInvoke("4", [][]byte{[]byte("setOptions"),
+		[]byte(`{"registration":110,"stateDatabase":"` + StateDatabaseLevelDB + `"}`)})
+	equals(t, int32(200), response.GetStatus())
+
+	// Only equality on the searchFields can be answered without CouchDB
+	for _, filter := range []string{`"amount":110`, `{"walletId":{"$regex":".*"}}`} {
+		response = mockStub.MockInvoke("5", [][]byte{[]byte("searchWalletTransactions"), []byte(filter)})
+		equals(t, int32(400), response.GetStatus())
+	}
+
+	response = mockStub.MockInvoke("6", [][]byte{[]byte("setOptions"),
+		[]byte(`{"registration":110,"stateDatabase":"mongodb"}`)})
+	equals(t, int32(400), response.GetStatus())
+}
+
+func TestSearchFallbackNegative(t *testing.T) {
+	t.Log("Test searches only fall back to composite keys when rich queries are unsupported")
+	mockStub := newRangeQueryStub(t)
+	mockStub.queryErr = errors.New("GET_QUERY_RESULT failed: transaction ID: 4: timeout")
+
+	response := mockStub.MockInvoke("4", [][]byte{[]byte("searchWallets"), []byte("")})
+	equals(t, CodeInternal.Status(), response.GetStatus())
+}
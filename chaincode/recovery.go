@@ -0,0 +1,127 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Reason codes of a wallet recovery, recorded on its wallet transactions.
+const (
+	RecoveryLostAccess  = "LOST_ACCESS"
+	RecoveryCompromised = "COMPROMISED"
+	RecoveryMerge       = "MERGE"
+	RecoveryOther       = "OTHER"
+)
+
+var recoveryReasons = []string{RecoveryLostAccess, RecoveryCompromised, RecoveryMerge, RecoveryOther}
+
+const RecoveryAction = "RECOVERY"
+
+func walletClosedError(wallet *Wallet) error {
+	return newError(CodeForbidden, "Wallet with id "+wallet.ID+" is closed, its successor is "+wallet.SuccessorID)
+}
+
+// recoverWallet moves the balance of a wallet its owner lost access to to a
+// successor wallet, which is created when it does not exist. The wallet is
+// closed with a pointer to the successor, and both sides get a RECOVERY
+// wallet transaction whose action entity is the other wallet. The wallet
+// keeps its mobile hash, so a lookup by mobile hash finds the closed wallet
+// and its successor; neither its mobile hash nor its key can change anymore.
+func (s *SmartContract) recoverWallet(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 3 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 3"))
+	}
+
+	walletID := args[0]
+	successorID := args[1]
+	reason := args[2]
+
+	customer := DefaultCustomer
+	if len(args) >= 4 && args[3] != "" {
+		customer = args[3]
+	}
+
+	if !containsString(recoveryReasons, reason) {
+		return errorResponse(newError(CodeInvalidArgument, "Invalid argument reason: expecting one of "+strings.Join(recoveryReasons, ", ")))
+	}
+
+	if successorID == "" || successorID == walletID {
+		return errorResponse(newError(CodeInvalidArgument, "Invalid argument successorId: expecting another wallet"))
+	}
+
+	wallet, err := getWalletObject(stub, walletID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if wallet.Closed {
+		return errorResponse(walletClosedError(wallet))
+	}
+
+	successor, err := getWalletObject(stub, successorID)
+	if err != nil {
+		if chaincodeErr, isChaincodeErr := err.(*Error); !isChaincodeErr || chaincodeErr.Code != CodeNotFound {
+			return errorResponse(err)
+		}
+
+		successor = new(Wallet)
+		successor.ObjectType = WalletObjectType
+		successor.SchemaVersion = schemaVersion(WalletObjectType)
+		successor.ID = successorID
+	}
+
+	if successor.Closed {
+		return errorResponse(walletClosedError(successor))
+	}
+
+	amount := wallet.Amount
+	successor.Amount += amount
+	wallet.Amount = 0
+	wallet.Closed = true
+	wallet.SuccessorID = successorID
+
+	for _, transaction := range []*WalletTransaction{
+		{WalletID: walletID, Amount: -amount, ActionEntityID: successorID},
+		{WalletID: successorID, Amount: amount, ActionEntityID: walletID},
+	} {
+		transaction.TxID = stub.GetTxID()
+		transaction.Type = "recovery"
+		transaction.Action = RecoveryAction
+		transaction.Customer = customer
+		transaction.LinkedWalletID = transaction.ActionEntityID
+		transaction.Reason = reason
+
+		err = s.putWalletTransaction(stub, transaction)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	for _, w := range []*Wallet{successor, wallet} {
+		key, err := stub.CreateCompositeKey(WalletObjectType, []string{w.ID})
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		asBytes, err := json.Marshal(w)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = stub.PutState(key, asBytes)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	walletAsBytes, err := json.Marshal(wallet)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(walletAsBytes)
+}
@@ -0,0 +1,93 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRecoverWallet(t *testing.T) {
+	t.Log("Test recoverWallet moves the balance to a new or existing successor")
+	f := newFixture(t).withWallet("w1", "hash1", 100).withWallet("w2", "hash2", 50).withWallet("w3", "hash3", 10).build()
+	supply := f.treasure().Balance
+
+	// ---- New successor ----
+	response := f.invoke("recoverWallet", "w1", "w4", RecoveryLostAccess)
+	equals(t, int32(200), response.GetStatus())
+
+	old := f.wallet("w1")
+	equals(t, 0.0, old.Amount)
+	equals(t, true, old.Closed)
+	equals(t, "w4", old.SuccessorID)
+	equals(t, 100.0, f.wallet("w4").Amount)
+
+	// The closed wallet is still found by its mobile hash, and points to the successor
+	equals(t, "w4", f.walletByMobileHash("hash1").SuccessorID)
+
+	response = f.invoke("searchWalletTransactions", `{"action":"`+RecoveryAction+`"}`)
+	equals(t, int32(200), response.GetStatus())
+
+	var transactions []WalletTransaction
+	ok(t, json.Unmarshal(response.GetPayload(), &transactions))
+	equals(t, 2, len(transactions))
+	for _, transaction := range transactions {
+		equals(t, RecoveryLostAccess, transaction.Reason)
+		switch transaction.WalletID {
+		case "w1":
+			equals(t, -100.0, transaction.Amount)
+			equals(t, "w4", transaction.LinkedWalletID)
+		case "w4":
+			equals(t, 100.0, transaction.Amount)
+			equals(t, "w1", transaction.LinkedWalletID)
+		default:
+			t.Fatalf("unexpected recovery transaction of wallet %s", transaction.WalletID)
+		}
+		equals(t, transactions[0].TxID, transaction.TxID)
+	}
+
+	// ---- Existing successor ----
+	response = f.invoke("recoverWallet", "w2", "w3", RecoveryMerge)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, 60.0, f.wallet("w3").Amount)
+	equals(t, 0.0, f.wallet("w2").Amount)
+
+	// Coins only move between wallets
+	equals(t, supply, f.treasure().Balance)
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestRecoverWalletNegative(t *testing.T) {
+	t.Log("Test recoverWallet Negative")
+	f := newFixture(t).withAdmins("Org1MSP").withWallet("w1", "hash1", 100).withWallet("w2", "hash2", 50).build()
+
+	response := f.invokeAs(f.as("Org2MSP", "user2"), "recoverWallet", "w1", "w3", RecoveryLostAccess)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invoke("recoverWallet", "w1", "w3", "")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	response = f.invoke("recoverWallet", "w1", "w3", RecoveryCompromised)
+	equals(t, int32(200), response.GetStatus())
+
+	// Closed wallets cannot move coins or be recovered to,
+	response = f.invoke("purchaseCoins", "w1", "10", "order", "1")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invoke("spendCoins", "w1", "10", "order", "2")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invoke("recoverWallet", "w2", "w1", RecoveryMerge)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	// nor change their key or mobile hash
+	response = f.invoke("rotateWalletKey", "w1", testWalletKey)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invoke("updateWalletMobileHash", "w1", "hash3")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+	equals(t, "w1", f.walletByMobileHash("hash1").ID)
+
+	equals(t, 0.0, f.wallet("w1").Amount)
+	equals(t, 50.0, f.wallet("w2").Amount)
+	equals(t, 100.0, f.wallet("w3").Amount)
+}
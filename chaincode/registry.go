@@ -0,0 +1,218 @@
+package chaincode
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Roles an invoker needs to call a function.
+const (
+	// RoleAny lets every identity of the channel call the function
+	RoleAny = ""
+	// RoleAdmin requires a member of the adminMSPs of the default options
+	RoleAdmin = "admin"
+)
+
+// handlerFunc implements a function. The arguments are positional and have
+// already been checked against the schema of the function.
+type handlerFunc func(s *SmartContract, stub shim.ChaincodeStubInterface, args []string) sc.Response
+
+// Function is an invoke function of the chaincode. Args lists its arguments
+// in positional order; a function can also be called with a single JSON
+// object argument holding them by name. ReadOnly functions cannot write to
+// the ledger, and every other function is stopped by a pause unless it is
+// PauseExempt. Returns names the document of the response payload, prefixed
+// with [] for an array of them, and is empty when there is no payload.
+type Function struct {
+	Name        string     `json:"name"`
+	Args        []ArgField `json:"args"`
+	Role        string     `json:"role,omitempty"`
+	ReadOnly    bool       `json:"readOnly,omitempty"`
+	PauseExempt bool       `json:"pauseExempt,omitempty"`
+	Returns     string     `json:"returns,omitempty"`
+	handler     handlerFunc
+	call        handlerFunc
+}
+
+// registry holds the invoke functions by name. Their handlers are wrapped in
+// the middleware pipeline when they are registered.
+var registry = map[string]*Function{}
+
+func register(functions ...Function) {
+	for i := range functions {
+		fn := &functions[i]
+		if fn.Args == nil {
+			fn.Args = []ArgField{}
+		}
+		if _, found := registry[fn.Name]; found {
+			panic("function " + fn.Name + " is registered twice")
+		}
+		fn.call = applyPipeline(fn)
+		registry[fn.Name] = fn
+	}
+}
+
+// Functions returns the invoke functions sorted by name.
+func Functions() []Function {
+	var functions []Function
+	for _, fn := range registry {
+		functions = append(functions, *fn)
+	}
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return functions
+}
+
+var searchArgs = []ArgField{
+	{Name: "filter", Type: ArgObject},
+	{Name: "page", Type: ArgInteger, Default: "1"},
+	{Name: "size", Type: ArgInteger, Default: "10"},
+}
+
+var coinsArgs = []ArgField{
+	{Name: "walletId", Type: ArgString, Required: true},
+	{Name: "amount", Type: ArgNumber, Required: true},
+	{Name: "action", Type: ArgString, Required: true},
+	{Name: "actionEntityId", Type: ArgString, Required: true},
+	{Name: "customer", Type: ArgString, Default: DefaultCustomer},
+}
+
+var supplyArgs = []ArgField{
+	{Name: "amount", Type: ArgNumber, Required: true},
+	{Name: "action", Type: ArgString, Required: true},
+	{Name: "actionEntityId", Type: ArgString, Required: true},
+}
+
+// signatureArgFields end the arguments of the operations a wallet signs,
+// see signature.go
+var signatureArgFields = []ArgField{
+	{Name: "nonce", Type: ArgInteger},
+	{Name: "expiry", Type: ArgInteger},
+	{Name: "signature", Type: ArgString},
+}
+
+var spendArgs = append(append([]ArgField(nil), coinsArgs...), signatureArgFields...)
+
+var proposalIDArgs = []ArgField{
+	{Name: "proposalId", Type: ArgString, Required: true},
+}
+
+func init() {
+	register(
+		Function{Name: "createWallet", Returns: WalletObjectType, handler: (*SmartContract).createWallet, Args: []ArgField{
+			{Name: "walletId", Type: ArgString, Required: true},
+			// Rejected once the mobile index key is set, pass the mobile in the
+			// transient map instead
+			{Name: "mobileHash", Type: ArgString, Sensitive: true, Deprecated: true},
+			{Name: "amount", Type: ArgNumber},
+			{Name: "action", Type: ArgString, Default: DefaultAction},
+			{Name: "actionEntityId", Type: ArgString, Default: DefaultActionEntityId},
+			{Name: "customer", Type: ArgString, Default: DefaultCustomer},
+			{Name: "publicKey", Type: ArgString},
+		}},
+		Function{Name: "getWallet", ReadOnly: true, Returns: WalletObjectType, handler: (*SmartContract).getWallet, Args: []ArgField{
+			{Name: "walletId", Type: ArgString, Required: true},
+		}},
+		Function{Name: "getWalletByMobileHash", ReadOnly: true, Returns: WalletObjectType, handler: (*SmartContract).getWalletByMobileHash, Args: []ArgField{
+			{Name: "mobileHash", Type: ArgString, Sensitive: true},
+		}},
+		Function{Name: "getWalletNonce", ReadOnly: true, Returns: WalletNonceObjectType, handler: (*SmartContract).getWalletNonce, Args: []ArgField{
+			{Name: "walletId", Type: ArgString, Required: true},
+		}},
+		Function{Name: "searchWallets", ReadOnly: true, Returns: "[]" + WalletObjectType, handler: (*SmartContract).searchWallets, Args: searchArgs},
+		Function{Name: "updateWalletMobileHash", Returns: WalletObjectType, handler: (*SmartContract).updateWalletMobileHash, Args: []ArgField{
+			{Name: "walletId", Type: ArgString, Required: true},
+			{Name: "mobileHash", Type: ArgString, Sensitive: true, Deprecated: true},
+		}},
+		Function{Name: "purgeWalletMobile", Role: RoleAdmin, Returns: WalletObjectType, handler: (*SmartContract).purgeWalletMobile, Args: []ArgField{
+			{Name: "walletId", Type: ArgString, Required: true},
+		}},
+		Function{Name: "recoverWallet", Role: RoleAdmin, Returns: WalletObjectType, handler: (*SmartContract).recoverWallet, Args: []ArgField{
+			{Name: "walletId", Type: ArgString, Required: true},
+			{Name: "successorId", Type: ArgString, Required: true},
+			{Name: "reason", Type: ArgString, Required: true},
+			{Name: "customer", Type: ArgString, Default: DefaultCustomer},
+		}},
+		Function{Name: "setMobileIndexKey", Role: RoleAdmin, handler: (*SmartContract).setMobileIndexKey},
+		Function{Name: "purgeLegacyMobileHashes", Role: RoleAdmin, Returns: "migrationProgress", handler: (*SmartContract).purgeLegacyMobileHashes, Args: []ArgField{
+			{Name: "keys", Type: ArgVariadic},
+		}},
+		Function{Name: "searchWalletTransactions", ReadOnly: true, Returns: "[]" + WalletTransactionObjectType, handler: (*SmartContract).searchWalletTransactions, Args: searchArgs},
+		Function{Name: "searchTreasureTransactions", ReadOnly: true, Returns: "[]" + TreasureTransactionObjectType, handler: (*SmartContract).searchTreasureTransactions, Args: searchArgs},
+		Function{Name: "createTreasure", Role: RoleAdmin, Returns: TreasureObjectType, handler: (*SmartContract).createTreasure, Args: []ArgField{
+			{Name: "balance", Type: ArgNumber},
+			{Name: "treasureId", Type: ArgString, Default: TreasureID},
+		}},
+		Function{Name: "getTreasure", ReadOnly: true, Returns: TreasureObjectType, handler: (*SmartContract).getTreasure, Args: []ArgField{
+			{Name: "treasureId", Type: ArgString, Default: TreasureID},
+		}},
+		Function{Name: "mintCoins", Role: RoleAdmin, Returns: TreasureObjectType, handler: (*SmartContract).mintCoins, Args: supplyArgs},
+		Function{Name: "burnCoins", Role: RoleAdmin, Returns: TreasureObjectType, handler: (*SmartContract).burnCoins, Args: supplyArgs},
+		Function{Name: "proposeTreasuryOp", Role: RoleAdmin, Returns: TreasuryProposalObjectType, handler: (*SmartContract).proposeTreasuryOp, Args: []ArgField{
+			{Name: "operation", Type: ArgString, Required: true},
+			{Name: "amount", Type: ArgNumber, Required: true},
+			{Name: "action", Type: ArgString, Required: true},
+			{Name: "actionEntityId", Type: ArgString, Required: true},
+			{Name: "walletId", Type: ArgString},
+			{Name: "customer", Type: ArgString, Default: DefaultCustomer},
+		}},
+		// Approvals are checked against the MSPs required by the proposal
+		Function{Name: "approveTreasuryOp", Returns: TreasuryProposalObjectType, handler: (*SmartContract).approveTreasuryOp, Args: proposalIDArgs},
+		Function{Name: "executeTreasuryOp", Role: RoleAdmin, Returns: TreasuryProposalObjectType, handler: (*SmartContract).executeTreasuryOp, Args: proposalIDArgs},
+		Function{Name: "getTreasuryOp", ReadOnly: true, Returns: TreasuryProposalObjectType, handler: (*SmartContract).getTreasuryOp, Args: proposalIDArgs},
+		Function{Name: "purchaseCoins", handler: (*SmartContract).purchaseCoins, Args: coinsArgs},
+		Function{Name: "spendCoins", handler: (*SmartContract).spendCoins, Args: spendArgs},
+		Function{Name: "executeSigned", handler: (*SmartContract).executeSigned, Args: append([]ArgField{
+			{Name: "operation", Type: ArgObject, Required: true},
+			{Name: "sponsorWalletId", Type: ArgString},
+			{Name: "relayFee", Type: ArgNumber},
+			{Name: "relayerWalletId", Type: ArgString},
+		}, signatureArgFields...)},
+		Function{Name: "rotateWalletKey", Returns: WalletObjectType, handler: (*SmartContract).rotateWalletKey, Args: append([]ArgField{
+			{Name: "walletId", Type: ArgString, Required: true},
+			{Name: "publicKey", Type: ArgString, Required: true},
+		}, signatureArgFields...)},
+		Function{Name: "setOptions", Role: RoleAdmin, Returns: OptionsObjectType, handler: (*SmartContract).setOptions, Args: []ArgField{
+			{Name: "registration", Type: ArgNumber, Required: true},
+			{Name: "customer", Type: ArgString, Default: DefaultCustomer},
+			{Name: "maxSupply", Type: ArgNumber, Named: true},
+			{Name: "approvalLimit", Type: ArgNumber, Named: true},
+			{Name: "approvalThreshold", Type: ArgInteger, Named: true},
+			{Name: "adminMSPs", Type: ArgList, Named: true},
+			{Name: "proposalTTL", Type: ArgInteger, Named: true},
+			{Name: "stateDatabase", Type: ArgString, Named: true},
+		}},
+		Function{Name: "getOptions", ReadOnly: true, Returns: OptionsObjectType, handler: (*SmartContract).getOptions, Args: []ArgField{
+			{Name: "customer", Type: ArgString, Default: DefaultCustomer},
+		}},
+		Function{Name: "setEndorsementPolicy", Role: RoleAdmin, Returns: "endorsementPolicy", handler: (*SmartContract).setEndorsementPolicy, Args: []ArgField{
+			{Name: "target", Type: ArgString, Required: true},
+			{Name: "id", Type: ArgString, Required: true},
+			{Name: "orgs", Type: ArgVariadic},
+		}},
+		Function{Name: "getEndorsementPolicy", ReadOnly: true, Returns: "endorsementPolicy", handler: (*SmartContract).getEndorsementPolicy, Args: []ArgField{
+			{Name: "target", Type: ArgString, Required: true},
+			{Name: "id", Type: ArgString},
+		}},
+		Function{Name: "getMigrationPage", Role: RoleAdmin, ReadOnly: true, Returns: "migrationPage", handler: (*SmartContract).getMigrationPage, Args: []ArgField{
+			{Name: "docType", Type: ArgString, Required: true},
+			{Name: "bookmark", Type: ArgString},
+			{Name: "pageSize", Type: ArgInteger},
+		}},
+		Function{Name: "migrate", Role: RoleAdmin, Returns: "migrationProgress", handler: (*SmartContract).migrate, Args: []ArgField{
+			{Name: "docType", Type: ArgString, Required: true},
+			{Name: "keys", Type: ArgVariadic},
+		}},
+		Function{Name: "describe", ReadOnly: true, handler: (*SmartContract).describe},
+		Function{Name: "pauseContract", Role: RoleAdmin, PauseExempt: true, Returns: PauseObjectType, handler: (*SmartContract).pauseContract, Args: []ArgField{
+			{Name: "customer", Type: ArgString},
+			{Name: "functions", Type: ArgList},
+			{Name: "reason", Type: ArgString},
+		}},
+		Function{Name: "unpauseContract", Role: RoleAdmin, PauseExempt: true, handler: (*SmartContract).unpauseContract, Args: []ArgField{
+			{Name: "customer", Type: ArgString},
+		}},
+		Function{Name: "getPauses", ReadOnly: true, Returns: "[]" + PauseObjectType, handler: (*SmartContract).getPauses},
+	)
+}
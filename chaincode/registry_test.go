@@ -0,0 +1,51 @@
+package chaincode
+
+import (
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Log("Test every invoke function is registered with a schema")
+	f := newFixture(t).build()
+
+	for name, fn := range registry {
+		equals(t, name, fn.Name)
+		assert(t, fn.handler != nil && fn.call != nil, "%s has no handler", name)
+		assert(t, fn.Role == RoleAny || fn.Role == RoleAdmin, "%s has the unknown role %q", name, fn.Role)
+		for i, field := range fn.Args {
+			assert(t, field.Type != ArgVariadic || i == len(fn.Args)-1, "%s: only the last argument can be variadic", name)
+		}
+	}
+
+	for _, invocation := range fuzzInvocations {
+		_, found := registry[invocation[0]]
+		assert(t, found, "%s is not registered", invocation[0])
+	}
+
+	functions := Functions()
+	equals(t, len(registry), len(functions))
+	for i := 1; i < len(functions); i++ {
+		assert(t, functions[i-1].Name < functions[i].Name, "functions are not sorted")
+	}
+
+	response := f.invoke("unknownFunction", `{"walletId":"w1"}`)
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+	equals(t, "Invalid Smart contract function name.", response.GetMessage())
+}
+
+func TestReadOnlyFunctions(t *testing.T) {
+	t.Log("Test read-only functions leave the ledger untouched")
+
+	for _, invocation := range fuzzInvocations {
+		if !registry[invocation[0]].ReadOnly {
+			continue
+		}
+
+		f := newFixture(t).withWallet("w1", "hash1", 100).build()
+		before := copyState(f.State)
+		response := f.invoke(invocation[0], invocation[1:]...)
+		assert(t, response.GetStatus() < 400 || response.GetStatus() == CodeNotFound.Status(),
+			"%s failed: %s", invocation[0], response.GetMessage())
+		equals(t, before, f.State)
+	}
+}
@@ -0,0 +1,184 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// A relayer submits the operations signed by the owner of a wallet on its
+// behalf, so that the owner needs no Fabric identity. The operation is run
+// through the pipeline of its function, which verifies the signature and
+// uses the nonce; executeSigned only relays operations on wallets with a
+// public key. The wallet transactions of a relayed call record the relayer.
+//
+// A sponsor wallet can pay the relayer a fee for the call. The fee is moved
+// from the sponsor wallet to the wallet of the relayer, and only with the
+// consent of the sponsor: a sponsor wallet must have a public key and sign
+// the payload returned by SponsorPayload. The payload binds the fee to the
+// operation, the relayer wallet and the identity of the relayer, so no
+// other relayer can charge the sponsor or redirect the fee.
+
+// OperationEnvelope is the operation relayed by executeSigned. Args holds
+// the arguments of the function, including its nonce, expiry and signature,
+// as a JSON array of positional arguments or as an object by name.
+type OperationEnvelope struct {
+	Function string          `json:"function"`
+	Args     json.RawMessage `json:"args"`
+}
+
+// relayedFunctions are the functions executeSigned relays, by the name of
+// their wallet argument.
+var relayedFunctions = map[string]string{
+	"spendCoins": "walletId",
+}
+
+const RelayFeeAction = "RELAY_FEE"
+
+// SponsorPayload returns the payload the sponsor of a relay fee signs with
+// its nonce and expiry: operation, sponsorWalletId, relayFee and
+// relayerWalletId as passed to executeSigned, followed by the MSP ID and the
+// client ID of the relayer that submits the transaction.
+func SponsorPayload(operation, sponsorWalletID, relayFee, relayerWalletID, relayerMSPID, relayerID, nonce, expiry string) []byte {
+	return OperationPayload("executeSigned", operation, sponsorWalletID, relayFee, relayerWalletID, relayerMSPID, relayerID, nonce, expiry)
+}
+
+// getRelayer returns the invoker when the transaction relays a signed
+// operation, and empty strings otherwise.
+func getRelayer(stub shim.ChaincodeStubInterface) (string, string, error) {
+
+	function, _ := stub.GetFunctionAndParameters()
+	if function != "executeSigned" {
+		return "", "", nil
+	}
+	return getInvoker(stub)
+}
+
+// decodeEnvelopeArgs returns the arguments of an envelope in the form the
+// pipeline of its function accepts.
+func decodeEnvelopeArgs(raw json.RawMessage) ([]string, error) {
+
+	var positional []string
+	if json.Unmarshal(raw, &positional) == nil {
+		return positional, nil
+	}
+
+	var named map[string]json.RawMessage
+	if json.Unmarshal(raw, &named) == nil && named != nil {
+		return []string{string(raw)}, nil
+	}
+
+	return nil, newError(CodeInvalidArgument, "Invalid argument operation: expecting args as an array or an object")
+}
+
+func (s *SmartContract) executeSigned(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	var envelope OperationEnvelope
+	err := json.Unmarshal([]byte(args[0]), &envelope)
+	if err != nil {
+		return errorResponse(newError(CodeInvalidArgument, "Invalid argument operation: "+err.Error()))
+	}
+
+	walletField, found := relayedFunctions[envelope.Function]
+	if !found {
+		return errorResponse(newError(CodeInvalidArgument, "Function "+envelope.Function+" cannot be relayed"))
+	}
+	fn := registry[envelope.Function]
+
+	operationArgs, err := decodeEnvelopeArgs(envelope.Args)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	positional, err := parseArgs(fn.Args, operationArgs)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	var walletID, customer = "", DefaultCustomer
+	for i, field := range fn.Args {
+		if i >= len(positional) {
+			break
+		}
+		switch field.Name {
+		case walletField:
+			walletID = positional[i]
+		case "customer":
+			if positional[i] != "" {
+				customer = positional[i]
+			}
+		}
+	}
+
+	wallet, err := getWalletObject(stub, walletID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if wallet.PublicKey == "" {
+		return errorResponse(newError(CodeForbidden, "Wallet with id "+walletID+" has no public key to verify a relayed operation"))
+	}
+
+	var sponsorID, relayerWalletID string
+	var fee float64
+	if len(args) >= 3 && args[2] != "" {
+		fee, err = parseAmount(args[2])
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	if fee > 0 {
+		if len(args) < 4 || args[1] == "" || args[3] == "" {
+			return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting a sponsorWalletId and a relayerWalletId for a relay fee"))
+		}
+		sponsorID, relayerWalletID = args[1], args[3]
+
+		// A transaction does not read its own writes, so every wallet can
+		// only be updated once
+		if sponsorID == walletID || relayerWalletID == walletID || sponsorID == relayerWalletID {
+			return errorResponse(newError(CodeInvalidArgument, "The wallet, sponsor wallet and relayer wallet must differ"))
+		}
+
+		sponsor, err := getWalletObject(stub, sponsorID)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if sponsor.PublicKey == "" {
+			return errorResponse(newError(CodeForbidden, "Sponsor wallet with id "+sponsorID+" has no public key to authorize a relay fee"))
+		}
+
+		relayerMSPID, relayerID, err := getInvoker(stub)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = checkWalletSignature(stub, sponsor, "executeSigned", append(args[:4:4], relayerMSPID, relayerID), signatureArgs(args, 4))
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	response := fn.call(s, stub, positional)
+	if response.GetStatus() >= shim.ERRORTHRESHOLD || fee == 0 {
+		return response
+	}
+
+	err = s.updateWalletBalance(stub, -fee, sponsorID, "relay fee", stub.GetTxID(), RelayFeeAction, stub.GetTxID(), customer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.updateWalletBalance(stub, fee, relayerWalletID, "relay fee", stub.GetTxID(), RelayFeeAction, stub.GetTxID(), customer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return response
+}
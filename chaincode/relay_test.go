@@ -0,0 +1,149 @@
+package chaincode
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// relayedSpend returns the envelope of a spendCoins signed by the test key.
+func relayedSpend(t *testing.T, nonce int, args ...string) string {
+	signed := signOperation(t, testEd25519Key, "spendCoins", nonce, time.Now().Add(time.Hour), args...)
+	positional := append(append(append([]string(nil), args...), DefaultCustomer), signed...)
+
+	operationArgs, err := json.Marshal(positional)
+	ok(t, err)
+
+	envelope, err := json.Marshal(OperationEnvelope{Function: "spendCoins", Args: operationArgs})
+	ok(t, err)
+	return string(envelope)
+}
+
+// sponsorSignature returns the nonce, expiry and signature arguments with
+// which the owner of key authorizes relayer to collect a relay fee.
+func sponsorSignature(t *testing.T, key crypto.Signer, relayer *invokerStub, nonce int, operation, sponsorWalletID, relayFee, relayerWalletID string) []string {
+	mspID, clientID, err := getInvoker(relayer)
+	ok(t, err)
+	return signOperation(t, key, "executeSigned", nonce, time.Now().Add(time.Hour), operation, sponsorWalletID, relayFee, relayerWalletID, mspID, clientID)
+}
+
+func TestExecuteSigned(t *testing.T) {
+	t.Log("Test executeSigned relays signed spends and charges the relay fee to the sponsor")
+	f := newFixture(t).withWallet("relayer", "hash3", 0).build()
+
+	response := f.invoke("createWallet", "w1", "hash1", "100", DefaultAction, "w1", DefaultCustomer, testWalletKey)
+	equals(t, int32(200), response.GetStatus())
+
+	sponsorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ok(t, err)
+	response = f.invoke("createWallet", "sponsor", "hash2", "50", DefaultAction, "sponsor", DefaultCustomer, encodeWalletKey(sponsorKey.Public()))
+	equals(t, int32(200), response.GetStatus())
+
+	relayer := f.as("Org2MSP", "relayer")
+	operation := relayedSpend(t, 1, "w1", "10", "PREDICTION", "P_1")
+	args := append([]string{operation, "sponsor", "2", "relayer"}, sponsorSignature(t, sponsorKey, relayer, 1, operation, "sponsor", "2", "relayer")...)
+	response = f.invokeAs(relayer, "executeSigned", args...)
+	equals(t, int32(200), response.GetStatus())
+
+	equals(t, 90.0, f.wallet("w1").Amount)
+	equals(t, 48.0, f.wallet("sponsor").Amount)
+	equals(t, 2.0, f.wallet("relayer").Amount)
+	equals(t, int64(1), f.walletNonce("w1").Nonce)
+	equals(t, int64(1), f.walletNonce("sponsor").Nonce)
+
+	response = f.invoke("searchWalletTransactions", `{"walletId":"w1","action":"PREDICTION"}`)
+	equals(t, int32(200), response.GetStatus())
+
+	var transactions []WalletTransaction
+	ok(t, json.Unmarshal(response.GetPayload(), &transactions))
+	equals(t, 1, len(transactions))
+	equals(t, "Org2MSP", transactions[0].RelayerMSPID)
+	assert(t, transactions[0].RelayerID != "", "relayer id is not recorded")
+
+	response = f.invoke("searchWalletTransactions", `{"walletId":"sponsor","action":"`+RelayFeeAction+`"}`)
+	equals(t, int32(200), response.GetStatus())
+
+	var fees []WalletTransaction
+	ok(t, json.Unmarshal(response.GetPayload(), &fees))
+	equals(t, 1, len(fees))
+	equals(t, -2.0, fees[0].Amount)
+
+	// Without a fee no sponsor is needed, and named arguments are accepted
+	signed := signOperation(t, testEd25519Key, "spendCoins", 2, time.Now().Add(time.Hour), "w1", "5", "PREDICTION", "P_2")
+	envelope := `{"function":"spendCoins","args":{"walletId":"w1","amount":5,"action":"PREDICTION","actionEntityId":"P_2",` +
+		`"nonce":2,"expiry":` + signed[1] + `,"signature":"` + signed[2] + `"}}`
+	response = f.invokeAs(relayer, "executeSigned", envelope)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, 85.0, f.wallet("w1").Amount)
+
+	// Spends that are not relayed do not record a relayer
+	response = f.invoke("spendCoins", "relayer", "1", "PREDICTION", "P_3")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("searchWalletTransactions", `{"walletId":"relayer","action":"PREDICTION"}`)
+	equals(t, int32(200), response.GetStatus())
+
+	var direct []WalletTransaction
+	ok(t, json.Unmarshal(response.GetPayload(), &direct))
+	equals(t, 1, len(direct))
+	equals(t, "", direct[0].RelayerMSPID)
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestExecuteSignedNegative(t *testing.T) {
+	t.Log("Test executeSigned Negative")
+	f := newFixture(t).withWallet("unsigned-sponsor", "hash2", 100).withWallet("relayer", "hash3", 0).withWallet("other-relayer", "hash5", 0).build()
+
+	response := f.invoke("createWallet", "w1", "hash1", "100", DefaultAction, "w1", DefaultCustomer, testWalletKey)
+	equals(t, int32(200), response.GetStatus())
+
+	sponsorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ok(t, err)
+	response = f.invoke("createWallet", "sponsor", "hash4", "100", DefaultAction, "sponsor", DefaultCustomer, encodeWalletKey(sponsorKey.Public()))
+	equals(t, int32(200), response.GetStatus())
+
+	relayer := f.as("Org2MSP", "relayer")
+	outsider := f.as("Org3MSP", "outsider")
+	expiry := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	operation := relayedSpend(t, 1, "w1", "10", "PREDICTION", "P_1")
+	signed := sponsorSignature(t, sponsorKey, relayer, 1, operation, "sponsor", "1", "relayer")
+
+	tests := []struct {
+		name    string
+		invoker *invokerStub
+		args    []string
+		status  int32
+	}{
+		{"fee without sponsor", relayer, []string{operation, "", "1"}, CodeInvalidArgument.Status()},
+		{"sponsor is the wallet", relayer, []string{operation, "w1", "1", "relayer"}, CodeInvalidArgument.Status()},
+		{"sponsor is the relayer", relayer, []string{operation, "relayer", "1", "relayer"}, CodeInvalidArgument.Status()},
+		{"unsigned sponsor", relayer, []string{operation, "unsigned-sponsor", "1", "relayer"}, CodeForbidden.Status()},
+		{"unknown sponsor", relayer, []string{operation, "unknown", "1", "relayer"}, CodeNotFound.Status()},
+		{"negative fee", relayer, []string{operation, "sponsor", "-1", "relayer"}, CodeInvalidArgument.Status()},
+		{"missing sponsor signature", relayer, []string{operation, "sponsor", "1", "relayer"}, CodeForbidden.Status()},
+		{"unrelated relayer", outsider, append([]string{operation, "sponsor", "1", "relayer"}, signed...), CodeForbidden.Status()},
+		{"redirected fee", outsider, append([]string{operation, "sponsor", "1", "other-relayer"}, signed...), CodeForbidden.Status()},
+		{"raised fee", relayer, append([]string{operation, "sponsor", "5", "relayer"}, signed...), CodeForbidden.Status()},
+		{"other operation", relayer, append([]string{relayedSpend(t, 1, "w1", "20", "PREDICTION", "P_1"), "sponsor", "1", "relayer"}, signed...), CodeForbidden.Status()},
+		{"invalid signature", relayer, []string{`{"function":"spendCoins","args":["w1","10","PREDICTION","P_1","ninjastack","1","` + expiry + `","c2lnbmF0dXJl"]}`}, CodeForbidden.Status()},
+		{"unknown wallet", relayer, []string{`{"function":"spendCoins","args":["unknown","10","PREDICTION","P_1"]}`}, CodeNotFound.Status()},
+	}
+
+	for _, test := range tests {
+		response = f.invokeAs(test.invoker, "executeSigned", test.args...)
+		assert(t, response.GetStatus() == test.status, "%s: expected %d, got %d: %s", test.name, test.status, response.GetStatus(), response.GetMessage())
+	}
+
+	equals(t, 100.0, f.wallet("w1").Amount)
+	equals(t, 100.0, f.wallet("sponsor").Amount)
+	equals(t, 0.0, f.wallet("relayer").Amount)
+	equals(t, 0.0, f.wallet("other-relayer").Amount)
+	equals(t, int64(0), f.walletNonce("w1").Nonce)
+	equals(t, int64(0), f.walletNonce("sponsor").Nonce)
+}
@@ -0,0 +1,323 @@
+package chaincode
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+const DefaultMigrationPageSize = 100
+
+// migration upgrades a stored document by exactly one schema version. The
+// stub lets a migration derive new fields from other documents.
+type migration func(stub shim.ChaincodeStubInterface, doc map[string]interface{}) error
+
+// migrations lists the upgrades of every docType in order: the upgrade from
+// version n to n+1 is at index n, so the current version of a docType is the
+// number of its migrations. Documents written before versioning are version 0.
+var migrations = map[string][]migration{
+	WalletObjectType:              {addSchemaVersion},
+	WalletTransactionObjectType:   {addSchemaVersion},
+	TreasureObjectType:            {addSchemaVersion, backfillTotalSupply},
+	TreasureTransactionObjectType: {addSchemaVersion},
+	OptionsObjectType:             {addSchemaVersion},
+	TreasuryProposalObjectType:    {addSchemaVersion},
+	PauseObjectType:               {},
+	WalletNonceObjectType:         {},
+}
+
+// documentIndexes adds a stored document to the indexes of its docType. It
+// runs on every document migrate scans, so migrating a docType also fills
+// indexes that were introduced after its documents were written.
+var documentIndexes = map[string]func(stub shim.ChaincodeStubInterface, data []byte) error{
+	WalletObjectType: indexWallet,
+}
+
+// A docType is migrated in pages. getMigrationPage lists the keys of one
+// page of documents, and migrate rewrites the documents of those keys. Peers
+// refuse writes in a transaction that runs a paginated query, so the two
+// cannot be combined, and without pagination every page would rescan the
+// documents before its bookmark.
+
+// MigrationPage is returned by getMigrationPage. Keys are the base64 encoded
+// keys to pass to migrate, and Bookmark continues with the next page until
+// Done is set.
+type MigrationPage struct {
+	DocType  string   `json:"docType"`
+	Keys     []string `json:"keys"`
+	Bookmark string   `json:"bookmark"`
+	Done     bool     `json:"done"`
+}
+
+// MigrationProgress is returned by migrate. The page the keys were listed in
+// is continued with the bookmark of its MigrationPage.
+type MigrationProgress struct {
+	DocType  string `json:"docType"`
+	Scanned  int    `json:"scanned"`
+	Migrated int    `json:"migrated"`
+}
+
+type documentHeader struct {
+	ObjectType    string `json:"docType"`
+	SchemaVersion int    `json:"schemaVersion"`
+}
+
+// addSchemaVersion is the first migration of every docType; the version
+// field itself is set by upgradeDocument.
+func addSchemaVersion(stub shim.ChaincodeStubInterface, doc map[string]interface{}) error {
+	return nil
+}
+
+// backfillTotalSupply sets the total supply of a treasury written before it
+// was tracked to the coins in circulation: the treasury balance and the
+// balances of all wallets, as resetTreasure counts them. Until migrate
+// rewrites the treasury, every read of it scans the wallets, so migrate it
+// right after the upgrade.
+func backfillTotalSupply(stub shim.ChaincodeStubInterface, doc map[string]interface{}) error {
+	if supply, _ := doc["totalSupply"].(float64); supply != 0 {
+		return nil
+	}
+
+	balance, _ := doc["balance"].(float64)
+	walletBalances, err := sumWalletBalances(stub)
+	if err != nil {
+		return err
+	}
+
+	doc["totalSupply"] = balance + walletBalances
+	return nil
+}
+
+// sumWalletBalances adds up the amounts of every wallet.
+func sumWalletBalances(stub shim.ChaincodeStubInterface) (float64, error) {
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(WalletObjectType, []string{})
+	if err != nil {
+		return 0, err
+	}
+	defer resultsIterator.Close()
+
+	var sum float64
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+
+		var wallet struct {
+			Amount float64 `json:"amount"`
+		}
+		err = json.Unmarshal(queryResponse.Value, &wallet)
+		if err != nil {
+			return 0, err
+		}
+		sum += wallet.Amount
+	}
+
+	return sum, nil
+}
+
+func schemaVersion(docType string) int {
+	return len(migrations[docType])
+}
+
+// upgradeDocument returns data migrated to the current schema version of its
+// docType. Documents that are already current are returned unchanged.
+func upgradeDocument(stub shim.ChaincodeStubInterface, data []byte) ([]byte, bool, error) {
+
+	if len(data) == 0 {
+		return data, false, nil
+	}
+
+	var header documentHeader
+	err := json.Unmarshal(data, &header)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := schemaVersion(header.ObjectType)
+	if header.SchemaVersion >= current {
+		return data, false, nil
+	}
+
+	var doc map[string]interface{}
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for version := header.SchemaVersion; version < current; version++ {
+		err = migrations[header.ObjectType][version](stub, doc)
+		if err != nil {
+			return nil, false, errors.New("migration of " + header.ObjectType + " to version " + strconv.Itoa(version+1) + " failed: " + err.Error())
+		}
+	}
+	doc["schemaVersion"] = current
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, true, nil
+}
+
+// unmarshalDocument decodes a stored document into v, migrating it first.
+func unmarshalDocument(stub shim.ChaincodeStubInterface, data []byte, v interface{}) error {
+
+	data, _, err := upgradeDocument(stub, data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// getMigrationPage lists the keys of up to pageSize documents of one docType,
+// starting at the bookmark of the previous page.
+func (s *SmartContract) getMigrationPage(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	docType := args[0]
+	if _, found := migrations[docType]; !found {
+		return errorResponse(newError(CodeInvalidArgument, "Invalid docType "+docType))
+	}
+
+	var bookmark string
+	if len(args) >= 2 && args[1] != "" {
+		key, err := base64.StdEncoding.DecodeString(args[1])
+		if err != nil {
+			return errorResponse(newError(CodeInvalidArgument, "Invalid bookmark: "+err.Error()))
+		}
+		bookmark = string(key)
+	}
+
+	pageSize := DefaultMigrationPageSize
+	if len(args) >= 3 && args[2] != "" {
+		size, err := strconv.Atoi(args[2])
+		if err != nil {
+			return errorResponse(newError(CodeInvalidArgument, err.Error()))
+		}
+		pageSize = size
+	}
+
+	if pageSize <= 0 {
+		return errorResponse(newError(CodeInvalidArgument, "Invalid argument pageSize: must be greater than zero"))
+	}
+
+	resultsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(docType, []string{}, int32(pageSize), bookmark)
+	if err != nil {
+		return errorResponse(err)
+	}
+	defer resultsIterator.Close()
+
+	var page = new(MigrationPage)
+	page.DocType = docType
+	page.Keys = []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return errorResponse(err)
+		}
+		page.Keys = append(page.Keys, base64.StdEncoding.EncodeToString([]byte(queryResponse.Key)))
+	}
+
+	if metadata.GetBookmark() != "" {
+		page.Bookmark = base64.StdEncoding.EncodeToString([]byte(metadata.GetBookmark()))
+	}
+	page.Done = page.Bookmark == ""
+
+	asBytes, err := json.Marshal(page)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+// migrate rewrites the documents of the keys of a MigrationPage to the
+// current schema version of their docType and adds them to its indexes.
+// Keys of deleted documents are skipped.
+func (s *SmartContract) migrate(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	docType := args[0]
+	if _, found := migrations[docType]; !found {
+		return errorResponse(newError(CodeInvalidArgument, "Invalid docType "+docType))
+	}
+
+	var progress = new(MigrationProgress)
+	progress.DocType = docType
+	for _, encoded := range args[1:] {
+		key, err := migrationKey(stub, docType, encoded)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		value, err := stub.GetState(key)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if len(value) == 0 {
+			continue
+		}
+
+		upgraded, changed, err := upgradeDocument(stub, value)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		if index, found := documentIndexes[docType]; found {
+			err = index(stub, upgraded)
+			if err != nil {
+				return errorResponse(err)
+			}
+		}
+
+		if changed {
+			err = stub.PutState(key, upgraded)
+			if err != nil {
+				return errorResponse(err)
+			}
+			progress.Migrated++
+		}
+
+		progress.Scanned++
+	}
+
+	asBytes, err := json.Marshal(progress)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(asBytes)
+}
+
+// migrationKey decodes a key of a MigrationPage and checks that it is a key
+// of docType.
+func migrationKey(stub shim.ChaincodeStubInterface, docType, encoded string) (string, error) {
+
+	keyBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", newError(CodeInvalidArgument, "Invalid key: "+err.Error())
+	}
+	key := string(keyBytes)
+
+	objectType, _, err := stub.SplitCompositeKey(key)
+	if err != nil || objectType != docType {
+		return "", newError(CodeInvalidArgument, "Invalid key: expecting a key of docType "+docType)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,170 @@
+package chaincode
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+var legacyWallet = `{"docType":"wallet","id":"legacy_wallet_id","amount":42,"mobileHash":"legacy"}`
+
+func putLegacyWallets(t *testing.T, mockStub *shim.MockStub, ids ...string) {
+	mockStub.MockTransactionStart("legacy")
+	for _, id := range ids {
+		key, err := mockStub.CreateCompositeKey(WalletObjectType, []string{id})
+		ok(t, err)
+		ok(t, mockStub.PutState(key, []byte(legacyWallet)))
+	}
+	mockStub.MockTransactionEnd("legacy")
+}
+
+func TestUpgradeDocument(t *testing.T) {
+	t.Log("Test upgradeDocument")
+	migrations["testDoc"] = []migration{
+		addSchemaVersion,
+		func(stub shim.ChaincodeStubInterface, doc map[string]interface{}) error {
+			doc["renamed"] = doc["name"]
+			delete(doc, "name")
+			return nil
+		},
+	}
+	defer delete(migrations, "testDoc")
+
+	stub := shim.NewMockStub("schema", new(SmartContract))
+	upgraded, changed, err := upgradeDocument(stub, []byte(`{"docType":"testDoc","name":"value"}`))
+	ok(t, err)
+	equals(t, true, changed)
+	equals(t, `{"docType":"testDoc","renamed":"value","schemaVersion":2}`, string(upgraded))
+
+	_, changed, err = upgradeDocument(stub, upgraded)
+	ok(t, err)
+	equals(t, false, changed)
+}
+
+func TestLazyMigration(t *testing.T) {
+	t.Log("Test lazy migration on read")
+	f := newFixture(t).build()
+	putLegacyWallets(t, f.MockStub, "legacy_wallet_id")
+
+	response := f.invoke("getWallet", "legacy_wallet_id")
+	equals(t, int32(200), response.GetStatus())
+
+	var wallet = new(Wallet)
+	err := json.Unmarshal(response.GetPayload(), wallet)
+	ok(t, err)
+	equals(t, schemaVersion(WalletObjectType), wallet.SchemaVersion)
+	equals(t, float64(42), wallet.Amount)
+}
+
+func TestMigrate(t *testing.T) {
+	t.Log("Test migrate")
+	f := newFixture(t).build()
+	putLegacyWallets(t, f.MockStub, "wallet_1", "wallet_2", "wallet_3")
+
+	var page = new(MigrationPage)
+	migrated := 0
+	for pages := 1; ; pages++ {
+		response := f.invoke("getMigrationPage", WalletObjectType, page.Bookmark, "2")
+		equals(t, int32(200), response.GetStatus())
+
+		err := json.Unmarshal(response.GetPayload(), page)
+		ok(t, err)
+
+		progress := f.migrate(WalletObjectType, page.Keys...)
+		equals(t, len(page.Keys), progress.Scanned)
+		migrated += progress.Migrated
+		if page.Done {
+			equals(t, 2, pages)
+			break
+		}
+	}
+	equals(t, 3, migrated)
+
+	key, err := f.CreateCompositeKey(WalletObjectType, []string{"wallet_3"})
+	ok(t, err)
+	var header documentHeader
+	err = json.Unmarshal(f.State[key], &header)
+	ok(t, err)
+	equals(t, schemaVersion(WalletObjectType), header.SchemaVersion)
+
+	// A second run finds nothing left to migrate
+	response := f.invoke("getMigrationPage", WalletObjectType)
+	err = json.Unmarshal(response.GetPayload(), page)
+	ok(t, err)
+	equals(t, true, page.Done)
+	equals(t, 0, f.migrate(WalletObjectType, page.Keys...).Migrated)
+}
+
+func TestBackfillTotalSupply(t *testing.T) {
+	t.Log("Test the supply of a legacy treasury covers the coins paid out to wallets")
+	f := newFixture(t).withTreasury(1000).withWallet("w1", "hash1", 30).withWallet("w2", "hash2", 20).build()
+
+	key, err := f.CreateCompositeKey(TreasureObjectType, []string{TreasureID})
+	ok(t, err)
+	f.MockTransactionStart("legacy")
+	ok(t, f.PutState(key, []byte(`{"docType":"treasure","balance":950}`)))
+	f.MockTransactionEnd("legacy")
+
+	// Read lazily, and rewritten by migrate
+	equals(t, float64(1000), f.treasure().TotalSupply)
+
+	progress := f.migrate(TreasureObjectType, base64.StdEncoding.EncodeToString([]byte(key)))
+	equals(t, 1, progress.Migrated)
+
+	var treasure = new(Treasure)
+	err = json.Unmarshal(f.State[key], treasure)
+	ok(t, err)
+	equals(t, Treasure{ObjectType: TreasureObjectType, SchemaVersion: schemaVersion(TreasureObjectType), Balance: 950, TotalSupply: 1000}, *treasure)
+
+	// ---- Negative Cases ----
+	// A supply that is already tracked is kept
+	f.MockTransactionStart("legacy")
+	ok(t, f.PutState(key, []byte(`{"docType":"treasure","balance":950,"totalSupply":1200}`)))
+	f.MockTransactionEnd("legacy")
+	equals(t, float64(1200), f.treasure().TotalSupply)
+}
+
+// migrate migrates the documents of keys and returns the progress.
+func (f *fixture) migrate(docType string, keys ...string) *MigrationProgress {
+	response := f.invoke("migrate", append([]string{docType}, keys...)...)
+	equals(f.t, int32(200), response.GetStatus())
+
+	var progress = new(MigrationProgress)
+	err := json.Unmarshal(response.GetPayload(), progress)
+	ok(f.t, err)
+	return progress
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestMigrateNegative(t *testing.T) {
+	t.Log("Test migrate Negative")
+	f := newFixture(t).build()
+	response := f.invoke("migrate", "unknownDoc")
+	equals(t, int32(400), response.GetStatus())
+
+	response = f.invoke("getMigrationPage", WalletObjectType, "%%%")
+	equals(t, int32(400), response.GetStatus())
+
+	for _, pageSize := range []string{"0", "-1"} {
+		response = f.invoke("getMigrationPage", WalletObjectType, "", pageSize)
+		equals(t, int32(400), response.GetStatus())
+	}
+
+	response = f.invoke("migrate", WalletObjectType, "%%%")
+	equals(t, int32(400), response.GetStatus())
+
+	// Keys of another docType are not migrated as wallets
+	key, err := f.CreateCompositeKey(TreasureObjectType, []string{TreasureID})
+	ok(t, err)
+	response = f.invoke("migrate", WalletObjectType, base64.StdEncoding.EncodeToString([]byte(key)))
+	equals(t, int32(400), response.GetStatus())
+
+	response = f.invokeAs(f.as("Org2MSP", "user2"), "getMigrationPage", WalletObjectType)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invokeAs(f.as("Org2MSP", "user2"), "migrate", WalletObjectType)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+}
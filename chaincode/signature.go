@@ -0,0 +1,223 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+	"golang.org/x/crypto/ed25519"
+)
+
+// A wallet with a public key only accepts the operations that move its coins
+// or change its key when they are signed by the key. Public keys are base64
+// encoded PKIX (SubjectPublicKeyInfo) ECDSA P-256 or Ed25519 keys.
+//
+// The signature covers the payload returned by OperationPayload and is
+// base64 encoded: an ASN.1 DER signature of its SHA-256 digest for ECDSA, or
+// the Ed25519 signature of the payload itself.
+
+var (
+	errInvalidSignature = newError(CodeForbidden, "Invalid signature")
+	errExpiredSignature = newError(CodeForbidden, "Signature has expired")
+)
+
+// The Go toolchains of the Fabric 1.4 chaincode environment predate Ed25519
+// support in crypto/x509 and ecdsa.VerifyASN1, so both are decoded here.
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// OperationPayload returns the canonical payload of a signed operation: the
+// JSON array of the function name, the arguments of the operation, the nonce
+// and the expiry. The function name keeps a signature for one function from
+// being replayed on another.
+func OperationPayload(function string, fields ...string) []byte {
+	payload, _ := json.Marshal(append([]string{function}, fields...))
+	return payload
+}
+
+// parseWalletKey decodes a public key of a wallet.
+func parseWalletKey(publicKey string) (interface{}, error) {
+
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, newError(CodeInvalidArgument, "Invalid public key: expecting base64")
+	}
+
+	var info subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err == nil && len(rest) == 0 && info.Algorithm.Algorithm.Equal(oidEd25519) {
+		if len(info.PublicKey.Bytes) == ed25519.PublicKeySize && info.PublicKey.BitLength == 8*ed25519.PublicKeySize {
+			return ed25519.PublicKey(info.PublicKey.Bytes), nil
+		}
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if key, isECDSA := key.(*ecdsa.PublicKey); err == nil && isECDSA && key.Curve == elliptic.P256() {
+		return key, nil
+	}
+
+	return nil, newError(CodeInvalidArgument, "Invalid public key: expecting an ECDSA P-256 or Ed25519 key")
+}
+
+// walletSignature is the nonce, expiry and signature of a signed operation.
+// The expiry is a unix time in seconds, compared to the transaction time.
+type walletSignature struct {
+	Nonce     string
+	Expiry    string
+	Signature string
+}
+
+// signatureArgs returns the signature of an operation whose arguments end
+// with the nonce, expiry and signature, starting at index first.
+func signatureArgs(args []string, first int) walletSignature {
+	var signed [3]string
+	for i := range signed {
+		if first+i < len(args) {
+			signed[i] = args[first+i]
+		}
+	}
+	return walletSignature{Nonce: signed[0], Expiry: signed[1], Signature: signed[2]}
+}
+
+// checkWalletSignature verifies the signature of an operation on a wallet,
+// whose arguments without the signature are args, and uses its nonce.
+// Wallets without a public key accept unsigned operations.
+func checkWalletSignature(stub shim.ChaincodeStubInterface, wallet *Wallet, function string, args []string, signature walletSignature) error {
+
+	if wallet.PublicKey == "" {
+		return nil
+	}
+
+	if signature.Signature == "" {
+		return newError(CodeForbidden, "Wallet with id "+wallet.ID+" requires a signature")
+	}
+
+	nonce, err := strconv.ParseInt(signature.Nonce, 10, 64)
+	if err != nil {
+		return newError(CodeInvalidArgument, "Invalid argument nonce: expecting an integer")
+	}
+
+	expiry, err := strconv.ParseInt(signature.Expiry, 10, 64)
+	if err != nil {
+		return newError(CodeInvalidArgument, "Invalid argument expiry: expecting an integer")
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return err
+	}
+
+	if now > expiry {
+		return errExpiredSignature
+	}
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return errInvalidSignature
+	}
+
+	key, err := parseWalletKey(wallet.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	fields := append(append([]string(nil), args...), signature.Nonce, signature.Expiry)
+	payload := OperationPayload(function, fields...)
+
+	var valid bool
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		var sig ecdsaSignature
+		rest, err := asn1.Unmarshal(signatureBytes, &sig)
+		if err == nil && len(rest) == 0 && sig.R != nil && sig.S != nil {
+			digest := sha256.Sum256(payload)
+			valid = ecdsa.Verify(key, digest[:], sig.R, sig.S)
+		}
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, payload, signatureBytes)
+	}
+
+	if !valid {
+		return errInvalidSignature
+	}
+
+	return useWalletNonce(stub, wallet.ID, nonce)
+}
+
+// rotateWalletKey sets the public key of a wallet. Replacing a key requires
+// a signature by the current key. The first key of an existing wallet can
+// only be set by an admin, since nothing proves that the invoker owns the
+// wallet; owners that hold a key pass it to createWallet instead.
+func (s *SmartContract) rotateWalletKey(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 2 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 2"))
+	}
+
+	walletID := args[0]
+	publicKey := args[1]
+
+	_, err := parseWalletKey(publicKey)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	key, err := stub.CreateCompositeKey(WalletObjectType, []string{walletID})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	wallet, err := getWalletObject(stub, walletID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if wallet.Closed {
+		return errorResponse(walletClosedError(wallet))
+	}
+
+	if wallet.PublicKey == "" {
+		err = s.checkAdmin(stub)
+	} else {
+		err = checkWalletSignature(stub, wallet, "rotateWalletKey", args[:2], signatureArgs(args, 2))
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	wallet.PublicKey = publicKey
+
+	err = s.createWalletTransaction(stub, 0, walletID, "key rotation", stub.GetTxID(), "KEY_ROTATION", stub.GetTxID(), DefaultCustomer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	walletAsBytes, err := json.Marshal(wallet)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = stub.PutState(key, walletAsBytes)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(walletAsBytes)
+}
@@ -0,0 +1,198 @@
+package chaincode
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	testEd25519Key = ed25519.NewKeyFromSeed([]byte("0123456789abcdef0123456789abcdef"))
+	testWalletKey  = encodeWalletKey(testEd25519Key.Public())
+)
+
+func encodeWalletKey(publicKey crypto.PublicKey) string {
+	var der []byte
+	var err error
+	if key, isEd25519 := publicKey.(ed25519.PublicKey); isEd25519 {
+		der, err = asn1.Marshal(subjectPublicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+			PublicKey: asn1.BitString{Bytes: key, BitLength: 8 * len(key)},
+		})
+	} else {
+		der, err = x509.MarshalPKIXPublicKey(publicKey)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+// signOperation returns the nonce, expiry and signature arguments of an
+// operation signed by key.
+func signOperation(t *testing.T, key crypto.Signer, function string, nonceArg int, expiry time.Time, args ...string) []string {
+	nonce := strconv.Itoa(nonceArg)
+	expiryArg := strconv.FormatInt(expiry.Unix(), 10)
+	payload := OperationPayload(function, append(args, nonce, expiryArg)...)
+
+	var signature []byte
+	var err error
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256(payload)
+		var sig ecdsaSignature
+		sig.R, sig.S, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		if err == nil {
+			signature, err = asn1.Marshal(sig)
+		}
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, payload)
+	}
+	ok(t, err)
+
+	return []string{nonce, expiryArg, base64.StdEncoding.EncodeToString(signature)}
+}
+
+func TestSignedSpend(t *testing.T) {
+	t.Log("Test spendCoins from wallets with a public key")
+	f := newFixture(t).build()
+	expiry := time.Now().Add(time.Hour)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ok(t, err)
+
+	response := f.invoke("createWallet", "w1", "hash1", "100", DefaultAction, "w1", DefaultCustomer, encodeWalletKey(ecdsaKey.Public()))
+	equals(t, int32(200), response.GetStatus())
+	equals(t, encodeWalletKey(ecdsaKey.Public()), f.wallet("w1").PublicKey)
+
+	args := []string{"w1", "10", "PREDICTION", "P_1"}
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, ecdsaKey, "spendCoins", 1, expiry, args...)...)...)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, 90.0, f.wallet("w1").Amount)
+
+	response = f.invoke("createWallet", "w2", "hash2", "100", DefaultAction, "w2", DefaultCustomer, testWalletKey)
+	equals(t, int32(200), response.GetStatus())
+
+	args = []string{"w2", "10", "PREDICTION", "P_2"}
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, testEd25519Key, "spendCoins", 1, expiry, args...)...)...)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, 90.0, f.wallet("w2").Amount)
+
+	// Wallets without a public key do not need a signature
+	response = f.invoke("createWallet", "w3", "hash3", "100")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("spendCoins", "w3", "10", "PREDICTION", "P_3")
+	equals(t, int32(200), response.GetStatus())
+}
+
+func TestRotateWalletKey(t *testing.T) {
+	t.Log("Test rotateWalletKey")
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, DefaultRegistrationAmount).build()
+	expiry := time.Now().Add(time.Hour)
+
+	// Admins set the first key without a signature
+	response := f.invoke("rotateWalletKey", defaultWalletID, testWalletKey)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, testWalletKey, f.wallet(defaultWalletID).PublicKey)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ok(t, err)
+	newKey := encodeWalletKey(ecdsaKey.Public())
+
+	// Replacing it needs a signature by the old key
+	response = f.invoke("rotateWalletKey", defaultWalletID, newKey)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invoke("rotateWalletKey", append([]string{defaultWalletID, newKey}, signOperation(t, ecdsaKey, "rotateWalletKey", 1, expiry, defaultWalletID, newKey)...)...)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+	equals(t, errInvalidSignature.Message, response.GetMessage())
+
+	response = f.invoke("rotateWalletKey", append([]string{defaultWalletID, newKey}, signOperation(t, testEd25519Key, "rotateWalletKey", 1, expiry, defaultWalletID, newKey)...)...)
+	equals(t, int32(200), response.GetStatus())
+	equals(t, newKey, f.wallet(defaultWalletID).PublicKey)
+
+	// The old key no longer authorizes spends
+	args := []string{defaultWalletID, "10", "PREDICTION", "P_1"}
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, testEd25519Key, "spendCoins", 2, expiry, args...)...)...)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, ecdsaKey, "spendCoins", 2, expiry, args...)...)...)
+	equals(t, int32(200), response.GetStatus())
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestSignedSpendNegative(t *testing.T) {
+	t.Log("Test signed spendCoins Negative")
+	f := newFixture(t).build()
+	expiry := time.Now().Add(time.Hour)
+
+	response := f.invoke("createWallet", "w1", "hash1", "100", DefaultAction, "w1", DefaultCustomer, "a2V5")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	// ECDSA keys on other curves are rejected
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	ok(t, err)
+	response = f.invoke("createWallet", "w1", "hash1", "100", DefaultAction, "w1", DefaultCustomer, encodeWalletKey(p384Key.Public()))
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	response = f.invoke("createWallet", "w1", "hash1", "100", DefaultAction, "w1", DefaultCustomer, testWalletKey)
+	equals(t, int32(200), response.GetStatus())
+
+	args := []string{"w1", "10", "PREDICTION", "P_1"}
+
+	response = f.invoke("spendCoins", args...)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signOperation(t, testEd25519Key, "spendCoins", 1, time.Now().Add(-time.Hour), args...)...)...)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+	equals(t, errExpiredSignature.Message, response.GetMessage())
+
+	// A signature covers the amount
+	signed := signOperation(t, testEd25519Key, "spendCoins", 1, expiry, args...)
+	response = f.invoke("spendCoins", append([]string{"w1", "50", "PREDICTION", "P_1", DefaultCustomer}, signed...)...)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+	equals(t, errInvalidSignature.Message, response.GetMessage())
+
+	// and the function
+	signed = signOperation(t, testEd25519Key, "purchaseCoins", 1, expiry, args...)
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signed...)...)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), "1", "1.5", "c2lnbmF0dXJl")...)
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	response = f.invoke("spendCoins", append(append(args, DefaultCustomer), signed[0], signed[1], "not base64")...)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+	equals(t, errInvalidSignature.Message, response.GetMessage())
+
+	equals(t, 100.0, f.wallet("w1").Amount)
+}
+
+func TestRotateWalletKeyNegative(t *testing.T) {
+	t.Log("Test rotateWalletKey Negative")
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, DefaultRegistrationAmount).build()
+
+	// Someone other than an admin cannot claim a wallet without a key
+	response := f.invokeAs(f.as("Org2MSP", "user2"), "rotateWalletKey", defaultWalletID, testWalletKey)
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+	equals(t, "", f.wallet(defaultWalletID).PublicKey)
+
+	response = f.invoke("rotateWalletKey", defaultWalletID, "a2V5")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	response = f.invoke("rotateWalletKey", "unknown_wallet_id", testWalletKey)
+	equals(t, CodeNotFound.Status(), response.GetStatus())
+}
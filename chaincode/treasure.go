@@ -0,0 +1,381 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+type Treasure struct {
+	ObjectType    string  `json:"docType"`
+	SchemaVersion int     `json:"schemaVersion"`
+	Balance       float64 `json:"balance"`
+	TotalSupply   float64 `json:"totalSupply"`
+}
+
+type TreasureTransaction struct {
+	ObjectType     string  `json:"docType"`
+	SchemaVersion  int     `json:"schemaVersion"`
+	TxID           string  `json:"txId"`
+	Type           string  `json:"type"`
+	Action         string  `json:"action"`
+	ActionEntityID string  `json:"actionEntityId"`
+	Amount         float64 `json:"amount"`
+	CreationDate   int64   `json:"creationDate"`
+	Customer       string  `json:"customer"`
+}
+
+func (s *SmartContract) createTreasure(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	var balance float64
+	if len(args) >= 1 && args[0] != "" {
+		b, err := strconv.ParseFloat(args[0], 64)
+		if err == nil {
+			balance = b
+		}
+	}
+
+	TreasureID := TreasureID
+	if len(args) >= 2 {
+		TreasureID = args[1]
+	}
+
+	key, err := stub.CreateCompositeKey(TreasureObjectType, []string{TreasureID})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	treasureAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if len(treasureAsBytes) != 0 {
+		return errorResponse(newError(CodeAlreadyExists, "Treasure with id "+TreasureID+" already exists"))
+	}
+
+	treasureAsBytes, err = s.putGenesisTreasure(stub, TreasureID, balance)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(treasureAsBytes)
+}
+
+// putGenesisTreasure writes a treasury holding the whole supply, replacing
+// any existing one, together with its genesis transaction.
+func (s *SmartContract) putGenesisTreasure(stub shim.ChaincodeStubInterface, treasureID string, balance float64) ([]byte, error) {
+
+	key, err := stub.CreateCompositeKey(TreasureObjectType, []string{treasureID})
+	if err != nil {
+		return nil, err
+	}
+
+	var treasure = new(Treasure)
+	treasure.ObjectType = TreasureObjectType
+	treasure.SchemaVersion = schemaVersion(TreasureObjectType)
+	treasure.Balance = balance
+	treasure.TotalSupply = balance
+
+	treasureAsBytes, err := json.Marshal(treasure)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stub.PutState(key, treasureAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	uuid := DefaultActionEntityId
+
+	err = s.createTreasureTransaction(stub, balance, "createTreasure", stub.GetTxID(), "genesis transaction", uuid, DefaultCustomer)
+	if err != nil {
+		return nil, err
+	}
+
+	return treasureAsBytes, nil
+}
+
+// resetTreasure sets the balance of the treasury on a reset. The coins held
+// by wallets are still in circulation, so the total supply changes by the
+// difference to the old balance, which is recorded as a reset transaction.
+func (s *SmartContract) resetTreasure(stub shim.ChaincodeStubInterface, balance, maxSupply float64) error {
+
+	key, err := stub.CreateCompositeKey(TreasureObjectType, []string{TreasureID})
+	if err != nil {
+		return err
+	}
+
+	treasureAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return err
+	}
+
+	var treasure = new(Treasure)
+	err = unmarshalDocument(stub, treasureAsBytes, treasure)
+	if err != nil {
+		return err
+	}
+
+	amount := balance - treasure.Balance
+	treasure.Balance = balance
+	treasure.TotalSupply += amount
+
+	if maxSupply > 0 && treasure.TotalSupply > maxSupply {
+		return newError(CodeInvalidArgument, "reset would exceed the maximum supply of "+strconv.FormatFloat(maxSupply, 'f', -1, 64))
+	}
+
+	err = s.createTreasureTransaction(stub, amount, "reset", stub.GetTxID(), "reset transaction", DefaultActionEntityId, DefaultCustomer)
+	if err != nil {
+		return err
+	}
+
+	treasureAsBytes, err = json.Marshal(treasure)
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(key, treasureAsBytes)
+}
+
+func (s *SmartContract) getTreasure(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+	TreasureID := TreasureID
+	if len(args) >= 1 {
+		TreasureID = args[0]
+	}
+
+	key, err := stub.CreateCompositeKey(TreasureObjectType, []string{TreasureID})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	treasureAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	treasureAsBytes, _, err = upgradeDocument(stub, treasureAsBytes)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(treasureAsBytes)
+}
+
+// mintCoins adds newly issued coins to the treasury, bounded by the
+// maxSupply option of the default customer when one is configured.
+func (s *SmartContract) mintCoins(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 3 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 3"))
+	}
+
+	amount, err := parseSupplyAmount(args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.checkDirectTreasuryOp(stub, amount)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	treasureAsBytes, err := s.updateTreasureSupply(stub, amount, "mint", stub.GetTxID(), args[1], args[2])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(treasureAsBytes)
+}
+
+// burnCoins destroys coins held by the treasury.
+func (s *SmartContract) burnCoins(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 3 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 3"))
+	}
+
+	amount, err := parseSupplyAmount(args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.checkDirectTreasuryOp(stub, amount)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	treasureAsBytes, err := s.updateTreasureSupply(stub, -amount, "burn", stub.GetTxID(), args[1], args[2])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(treasureAsBytes)
+}
+
+// checkDirectTreasuryOp limits the amount a transaction mints, burns or pays
+// out of the treasury to the approval limit. Larger amounts need a treasury
+// proposal, a withdraw for payouts to a wallet.
+func (s *SmartContract) checkDirectTreasuryOp(stub shim.ChaincodeStubInterface, amount float64) error {
+
+	options, err := s.getOptionsObject(stub, DefaultCustomer)
+	if err != nil {
+		return err
+	}
+
+	if options.ApprovalLimit > 0 && amount > options.ApprovalLimit {
+		return newError(CodeForbidden, "amount exceeds the approval limit of "+strconv.FormatFloat(options.ApprovalLimit, 'f', -1, 64)+", use proposeTreasuryOp")
+	}
+
+	return nil
+}
+
+func parseSupplyAmount(arg string) (float64, error) {
+
+	amount, err := parseAmount(arg)
+	if err != nil {
+		return 0, err
+	}
+
+	if amount <= 0 {
+		return 0, newError(CodeInvalidArgument, "amount must be greater than zero")
+	}
+
+	return amount, nil
+}
+
+// updateTreasureSupply changes both the treasury balance and the total supply
+// by amount, unlike updateTreasureBalance which only moves existing coins.
+func (s *SmartContract) updateTreasureSupply(stub shim.ChaincodeStubInterface,
+	amount float64,
+	transactionType, txnID, action, actionEntityID string) ([]byte, error) {
+
+	key, err := stub.CreateCompositeKey(TreasureObjectType, []string{TreasureID})
+	if err != nil {
+		return nil, err
+	}
+
+	treasureAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(treasureAsBytes) == 0 {
+		return nil, newError(CodeNotFound, "Treasure with id "+TreasureID+" not found")
+	}
+
+	var treasure = new(Treasure)
+	err = unmarshalDocument(stub, treasureAsBytes, treasure)
+	if err != nil {
+		return nil, err
+	}
+
+	treasure.Balance += amount
+	treasure.TotalSupply += amount
+	if treasure.Balance < 0 || treasure.TotalSupply < 0 {
+		return nil, newError(CodeTreasuryExhausted, "insufficient funds on treasure")
+	}
+
+	if math.IsInf(treasure.TotalSupply, 0) {
+		return nil, newError(CodeInvalidArgument, "mint would overflow the total supply")
+	}
+
+	if amount > 0 {
+		options, err := s.getOptionsObject(stub, DefaultCustomer)
+		if err != nil {
+			return nil, err
+		}
+
+		if options.MaxSupply > 0 && treasure.TotalSupply > options.MaxSupply {
+			return nil, newError(CodeInvalidArgument, "mint would exceed the maximum supply of "+strconv.FormatFloat(options.MaxSupply, 'f', -1, 64))
+		}
+	}
+
+	err = s.createTreasureTransaction(stub, amount, transactionType, txnID, action, actionEntityID, DefaultCustomer)
+	if err != nil {
+		return nil, err
+	}
+
+	treasureAsBytes, err = json.Marshal(treasure)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stub.PutState(key, treasureAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return treasureAsBytes, nil
+}
+
+func (s *SmartContract) updateTreasureBalance(stub shim.ChaincodeStubInterface,
+	amount float64,
+	transactionType, txnID, action, actionEntityID, customer string) error {
+
+	key, err := stub.CreateCompositeKey(TreasureObjectType, []string{TreasureID})
+	if err != nil {
+		return err
+	}
+
+	var treasure = new(Treasure)
+	treasureAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return err
+	}
+
+	err = unmarshalDocument(stub, treasureAsBytes, treasure)
+	if err != nil {
+		return err
+	}
+
+	treasure.Balance += amount
+	if treasure.Balance < 0 {
+		return newError(CodeTreasuryExhausted, "insufficient funds on treasure")
+	}
+
+	err = s.createTreasureTransaction(stub, amount, transactionType, txnID, action, actionEntityID, customer)
+	if err != nil {
+		return err
+	}
+
+	treasureAsBytes, err = json.Marshal(treasure)
+	if err != nil {
+		return err
+	}
+
+	err = stub.PutState(key, treasureAsBytes)
+	return err
+}
+
+func (s *SmartContract) createTreasureTransaction(stub shim.ChaincodeStubInterface,
+	amount float64,
+	transactionType, txnID, action, actionEntityID, customer string) error {
+
+	var key, err = stub.CreateCompositeKey(TreasureTransactionObjectType, []string{txnID, transactionType})
+	if err != nil {
+		return err
+	}
+
+	var transaction = new(TreasureTransaction)
+	transaction.ObjectType = TreasureTransactionObjectType
+	transaction.SchemaVersion = schemaVersion(TreasureTransactionObjectType)
+	transaction.Type = transactionType
+	transaction.TxID = txnID
+	transaction.Action = action
+	transaction.ActionEntityID = actionEntityID
+	transaction.Amount = amount
+	transaction.Customer = customer
+	transaction.CreationDate = time.Now().Unix()
+	trAsBytes, err := json.Marshal(transaction)
+	if err != nil {
+		return err
+	}
+
+	err = stub.PutState(key, trAsBytes)
+	return err
+}
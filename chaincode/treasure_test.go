@@ -0,0 +1,116 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetTreasure(t *testing.T) {
+	t.Log("Test getTreasure")
+	f := newFixture(t).build()
+
+	treasure := f.treasure()
+	equals(t, TreasureObjectType, treasure.ObjectType)
+	equals(t, float64(210000000), treasure.Balance)
+	equals(t, float64(210000000), treasure.TotalSupply)
+}
+
+func TestCreateTreasure(t *testing.T) {
+	t.Log("Test createTreasure")
+	f := newFixture(t).build()
+	response := f.invoke("createTreasure", "5421000", "test-ninjastack")
+	equals(t, int32(200), response.GetStatus())
+
+	var treasure = new(Treasure)
+	err := json.Unmarshal(response.GetPayload(), treasure)
+	ok(t, err)
+	equals(t, TreasureObjectType, treasure.ObjectType)
+	equals(t, float64(5421000), treasure.Balance)
+
+	// Test getTreasure again with new values
+	response = f.invoke("getTreasure", "test-ninjastack")
+	equals(t, int32(200), response.GetStatus())
+
+	err = json.Unmarshal(response.GetPayload(), treasure)
+	ok(t, err)
+	equals(t, float64(5421000), treasure.Balance)
+}
+
+func TestMintAndBurnCoins(t *testing.T) {
+	t.Log("Test mintCoins and burnCoins")
+	f := newFixture(t).build()
+	response := f.invoke("mintCoins", "1000", "MINT", "MINT_NUMBER_1")
+	equals(t, int32(200), response.GetStatus())
+
+	var treasure = new(Treasure)
+	err := json.Unmarshal(response.GetPayload(), treasure)
+	ok(t, err)
+	equals(t, float64(210001000), treasure.Balance)
+	equals(t, float64(210001000), treasure.TotalSupply)
+
+	response = f.invoke("burnCoins", "1000", "BURN", "BURN_NUMBER_1")
+	equals(t, int32(200), response.GetStatus())
+
+	treasure = f.treasure()
+	equals(t, float64(210000000), treasure.Balance)
+	equals(t, float64(210000000), treasure.TotalSupply)
+}
+
+func TestLegacyTreasureSupply(t *testing.T) {
+	t.Log("Test the total supply of a treasury written before it was tracked")
+	f := newFixture(t).withTreasury(1000).withOptions(Options{Customer: DefaultCustomer, Registration: DefaultRegistrationAmount, MaxSupply: 1500}).build()
+
+	key, err := f.CreateCompositeKey(TreasureObjectType, []string{TreasureID})
+	ok(t, err)
+	f.MockTransactionStart("legacy")
+	ok(t, f.PutState(key, []byte(`{"docType":"treasure","balance":1000}`)))
+	f.MockTransactionEnd("legacy")
+
+	equals(t, float64(1000), f.treasure().TotalSupply)
+
+	// The maximum supply applies
+	response := f.invoke("mintCoins", "501", "MINT", "MINT_NUMBER_1")
+	equals(t, CodeInvalidArgument.Status(), response.GetStatus())
+
+	response = f.invoke("burnCoins", "400", "BURN", "BURN_NUMBER_1")
+	equals(t, int32(200), response.GetStatus())
+	equals(t, float64(600), f.treasure().TotalSupply)
+}
+
+// ------------------------------------- Negative Cases --------------------------------------------------------
+
+func TestCreateTreasureNegative(t *testing.T) {
+	t.Log("Test createTreasure Negative")
+	f := newFixture(t).build()
+	response := f.invoke("createTreasure", "1")
+	equals(t, int32(412), response.GetStatus())
+	equals(t, float64(210000000), f.treasure().Balance)
+
+	response = f.invokeAs(f.as("Org2MSP", "user2"), "createTreasure", "1", "other")
+	equals(t, CodeForbidden.Status(), response.GetStatus())
+}
+
+func TestMintAndBurnCoinsNegative(t *testing.T) {
+	t.Log("Test mintCoins and burnCoins Negative")
+	f := newFixture(t).
+		withOptions(Options{Customer: DefaultCustomer, Registration: DefaultRegistrationAmount, MaxSupply: 210000500}).
+		build()
+
+	tests := []struct {
+		function string
+		amount   string
+		status   int32
+	}{
+		{"mintCoins", "1000", 400},
+		{"mintCoins", "0", 400},
+		{"burnCoins", "-1000", 400},
+		{"burnCoins", "210000001", 422},
+		{"burnCoins", "many", 400},
+	}
+
+	for _, test := range tests {
+		response := f.invoke(test.function, test.amount, "SUPPLY", "SUPPLY_"+test.amount)
+		equals(t, test.status, response.GetStatus())
+	}
+	equals(t, float64(210000000), f.treasure().Balance)
+}
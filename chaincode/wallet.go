@@ -0,0 +1,500 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+type Wallet struct {
+	ObjectType    string  `json:"docType"`
+	SchemaVersion int     `json:"schemaVersion"`
+	ID            string  `json:"id"`
+	Amount        float64 `json:"amount"`
+	MobileHash    string  `json:"mobileHash"`
+	// PublicKey authorizes the spends of the wallet when set, see signature.go
+	PublicKey string `json:"publicKey,omitempty"`
+	// Closed wallets cannot move coins. SuccessorID is the wallet their
+	// balance was recovered to, see recovery.go
+	Closed      bool   `json:"closed,omitempty"`
+	SuccessorID string `json:"successorId,omitempty"`
+	// Customer the wallet was registered for, which pauses of the customer
+	// apply to. Wallets registered before it was recorded have none.
+	Customer string `json:"customer,omitempty"`
+}
+
+type WalletTransaction struct {
+	ObjectType     string  `json:"docType"`
+	SchemaVersion  int     `json:"schemaVersion"`
+	WalletID       string  `json:"walletId"`
+	TxID           string  `json:"txId"`
+	Type           string  `json:"type"`
+	Action         string  `json:"action"`
+	ActionEntityID string  `json:"actionEntityId"`
+	Amount         float64 `json:"amount"`
+	CreationDate   int64   `json:"creationDate"`
+	Customer       string  `json:"customer"`
+	// RelayerMSPID and RelayerID identify the relayer of a call of
+	// executeSigned, see relay.go
+	RelayerMSPID string `json:"relayerMspId,omitempty"`
+	RelayerID    string `json:"relayerId,omitempty"`
+	// LinkedWalletID and Reason are set on the transactions of a recovery
+	LinkedWalletID string `json:"linkedWalletId,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+func (s *SmartContract) createWallet(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	argsLength := len(args)
+	if argsLength < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	customer := DefaultCustomer
+	if argsLength >= 6 {
+		customer = args[5]
+	}
+
+	action := DefaultAction
+	actionEntityID := DefaultActionEntityId
+
+	if argsLength >= 5 {
+		action = args[3]
+		actionEntityID = args[4]
+	}
+
+	var amount float64
+	if argsLength > 2 && args[2] != "" {
+		val, err := parseAmount(args[2])
+		if err != nil {
+			return errorResponse(err)
+		}
+		amount = val
+
+	} else {
+		options, err := s.getOptionsObject(stub, customer)
+		if err != nil {
+			return errorResponse(err)
+		}
+		amount = options.Registration
+
+	}
+
+	var wallet = new(Wallet)
+	if argsLength >= 7 && args[6] != "" {
+		_, err := parseWalletKey(args[6])
+		if err != nil {
+			return errorResponse(err)
+		}
+		wallet.PublicKey = args[6]
+	}
+
+	wallet.ObjectType = WalletObjectType
+	wallet.SchemaVersion = schemaVersion(WalletObjectType)
+	wallet.ID = args[0]
+	if argsLength >= 2 {
+		wallet.MobileHash = args[1]
+	}
+	wallet.Amount = amount
+	wallet.Customer = customer
+
+	Key, err := stub.CreateCompositeKey(WalletObjectType, []string{wallet.ID})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	asBytes, err := stub.GetState(Key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if len(asBytes) != 0 {
+		return errorResponse(newError(CodeAlreadyExists, "Wallet with id "+args[0]+" already exists"))
+	}
+
+	err = checkMobileHashArgument(stub, wallet.MobileHash)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	mobile, salt, found, err := getTransientMobile(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if found {
+		wallet.MobileHash, err = putWalletMobile(stub, wallet.ID, mobile, salt)
+		if err != nil {
+			return errorResponse(err)
+		}
+	} else if wallet.MobileHash == "" {
+		return errorResponse(errMissingMobile)
+	}
+
+	err = putMobileHashIndex(stub, "", wallet)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.checkDirectTreasuryOp(stub, amount)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.updateTreasureBalance(stub, -amount, "registration", stub.GetTxID(), action, actionEntityID, customer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.createWalletTransaction(stub, amount, wallet.ID, "registration", stub.GetTxID(), action, actionEntityID, customer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	walletAsBytes, err := json.Marshal(wallet)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = stub.PutState(Key, walletAsBytes)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(walletAsBytes)
+}
+
+func (s *SmartContract) getWallet(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+
+	key, err := stub.CreateCompositeKey(WalletObjectType, []string{args[0]})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	walletAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	walletAsBytes, _, err = upgradeDocument(stub, walletAsBytes)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(walletAsBytes)
+}
+
+// getWalletObject returns a wallet, or a NOT_FOUND error.
+func getWalletObject(stub shim.ChaincodeStubInterface, walletID string) (*Wallet, error) {
+
+	key, err := stub.CreateCompositeKey(WalletObjectType, []string{walletID})
+	if err != nil {
+		return nil, err
+	}
+
+	walletAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(walletAsBytes) == 0 {
+		return nil, newError(CodeNotFound, "Wallet with id "+walletID+" not found")
+	}
+
+	var wallet = new(Wallet)
+	err = unmarshalDocument(stub, walletAsBytes, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+func (s *SmartContract) purchaseCoins(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 4 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 4"))
+	}
+
+	customer := DefaultCustomer
+	if len(args) >= 5 {
+		customer = args[4]
+	}
+
+	var walletID = args[0]
+	amount, err := parseAmount(args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	action := args[2]
+	actionEntityID := args[3]
+
+	err = s.checkDirectTreasuryOp(stub, amount)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.updateTreasureBalance(stub, -amount, "purchase", stub.GetTxID(), action, actionEntityID, customer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.updateWalletBalance(stub, amount, walletID, "purchase", stub.GetTxID(), action, actionEntityID, customer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(nil)
+}
+
+func (s *SmartContract) spendCoins(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+
+	if len(args) < 4 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 2"))
+	}
+
+	customer := DefaultCustomer
+	if len(args) >= 5 {
+		customer = args[4]
+	}
+
+	var walletID = args[0]
+	amount, err := parseAmount(args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	action := args[2]
+	actionEntityID := args[3]
+
+	wallet, err := getWalletObject(stub, walletID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = checkWalletSignature(stub, wallet, "spendCoins", args[:4], signatureArgs(args, 5))
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.updateWalletBalance(stub, -amount, walletID, "spend", stub.GetTxID(), action, actionEntityID, customer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = s.updateTreasureBalance(stub, amount, "spend", stub.GetTxID(), action, actionEntityID, customer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(nil)
+}
+
+func (s *SmartContract) updateWalletBalance(stub shim.ChaincodeStubInterface,
+	amount float64,
+	walletID, transactionType, txnID, action, actionEntityID, customer string) error {
+
+	var key, err = stub.CreateCompositeKey(WalletObjectType, []string{walletID})
+	if err != nil {
+		return err
+	}
+
+	var wallet = new(Wallet)
+	byteWallets, err := stub.GetState(key)
+	if err != nil {
+		return err
+	}
+
+	if len(byteWallets) == 0 {
+		return newError(CodeNotFound, "Wallet with id "+walletID+" not found")
+	}
+
+	err = unmarshalDocument(stub, byteWallets, wallet)
+	if err != nil {
+		return err
+	}
+
+	if wallet.Closed {
+		return walletClosedError(wallet)
+	}
+
+	wallet.Amount = wallet.Amount + amount
+	if wallet.Amount < 0 {
+		return newError(CodeInsufficientFunds, "insufficient funds")
+	}
+
+	err = s.createWalletTransaction(stub, amount, walletID, transactionType, txnID, action, actionEntityID, customer)
+	if err != nil {
+		return err
+	}
+
+	asBytes, err := json.Marshal(wallet)
+	if err != nil {
+		return err
+	}
+
+	err = stub.PutState(key, asBytes)
+	return err
+}
+
+func (s *SmartContract) updateWalletMobileHash(stub shim.ChaincodeStubInterface, args []string) sc.Response {
+	if len(args) < 1 {
+		return errorResponse(newError(CodeInvalidArgument, "Incorrect number of arguments. Expecting 1"))
+	}
+	walletID := args[0]
+	mobileHash := ""
+	if len(args) >= 2 {
+		mobileHash = args[1]
+	}
+	key, err := stub.CreateCompositeKey(WalletObjectType, []string{walletID})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = checkMobileHashArgument(stub, mobileHash)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	mobile, salt, found, err := getTransientMobile(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if !found && mobileHash == "" {
+		return errorResponse(errMissingMobile)
+	}
+
+	// The action entity id is public and ends up in the blocks, so it must
+	// not carry the mobile hash
+	action := MobileUpdateAction
+	actionEntityID := stub.GetTxID()
+
+	walletAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if len(walletAsBytes) == 0 {
+		return errorResponse(newError(CodeNotFound, "Wallet with id "+walletID+" not found"))
+	}
+
+	var wallet = new(Wallet)
+	err = unmarshalDocument(stub, walletAsBytes, wallet)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if wallet.Closed {
+		return errorResponse(walletClosedError(wallet))
+	}
+
+	if found {
+		mobileHash, err = putWalletMobile(stub, walletID, mobile, salt)
+	} else {
+		err = delWalletMobile(stub, walletID)
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = putMobileHashIndex(stub, wallet.MobileHash, &Wallet{ID: walletID, MobileHash: mobileHash})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	wallet.MobileHash = mobileHash
+
+	err = s.createWalletTransaction(stub, 0, walletID, "mobile update", stub.GetTxID(), action, actionEntityID, DefaultCustomer)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	walletAsBytes, err = json.Marshal(wallet)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = stub.PutState(key, walletAsBytes)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(walletAsBytes)
+}
+
+func (s *SmartContract) createWalletTransaction(stub shim.ChaincodeStubInterface,
+	amount float64,
+	walletID, transactionType, txnID, action, actionEntityID, customer string) error {
+
+	var transaction = new(WalletTransaction)
+	transaction.TxID = txnID
+	transaction.Type = transactionType
+	transaction.WalletID = walletID
+	transaction.Amount = amount
+	transaction.Action = action
+	transaction.ActionEntityID = actionEntityID
+	transaction.Customer = customer
+	return s.putWalletTransaction(stub, transaction)
+}
+
+// putWalletTransaction stores a wallet transaction. It fails with a double
+// hit when the wallet already has a transaction for the action and action
+// entity.
+func (s *SmartContract) putWalletTransaction(stub shim.ChaincodeStubInterface, transaction *WalletTransaction) error {
+	var key, err = stub.CreateCompositeKey(WalletTransactionObjectType, []string{transaction.WalletID, transaction.Action, transaction.ActionEntityID})
+	if err != nil {
+		return err
+	}
+
+	walletTxn, err := stub.GetState(key)
+	if len(walletTxn) != 0 {
+		return errDoubleHit
+	}
+
+	transaction.ObjectType = WalletTransactionObjectType
+	transaction.SchemaVersion = schemaVersion(WalletTransactionObjectType)
+	transaction.CreationDate = time.Now().Unix()
+
+	transaction.RelayerMSPID, transaction.RelayerID, err = getRelayer(stub)
+	if err != nil {
+		return err
+	}
+
+	trAsBytes, err := json.Marshal(transaction)
+	if err != nil {
+		return err
+	}
+
+	err = stub.PutState(key, trAsBytes)
+	return err
+}
+
+// parseAmount parses an amount of coins to move between the treasury and a
+// wallet. Negative and non-finite amounts would let a transaction move coins
+// the wrong way or poison every later balance, so they are rejected.
+func parseAmount(arg string) (float64, error) {
+
+	amount, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, newError(CodeInvalidArgument, err.Error())
+	}
+
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, newError(CodeInvalidArgument, "amount must be a finite number")
+	}
+
+	if amount < 0 {
+		return 0, newError(CodeInvalidArgument, "amount must not be negative")
+	}
+
+	return amount, nil
+}
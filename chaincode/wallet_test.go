@@ -1,4 +1,4 @@
-package main
+package chaincode
 
 import (
 	"encoding/json"
@@ -10,9 +10,8 @@ var defaultMobileHash = "9ba3878af953abfc6d91e50f01d7bded407c601101497235dd4b60a
 
 func TestCreateWallet(t *testing.T) {
 	t.Log("Test createWallet")
-	response := stub.MockInvoke("1", [][]byte{[]byte("createWallet"),
-		[]byte(defaultWalletID),
-		[]byte(defaultMobileHash)})
+	f := newFixture(t).build()
+	response := f.invoke("createWallet", defaultWalletID, defaultMobileHash)
 	equals(t, int32(200), response.GetStatus())
 
 	var wallet = new(Wallet)
@@ -21,16 +20,14 @@ func TestCreateWallet(t *testing.T) {
 	equals(t, DefaultRegistrationAmount, int(wallet.Amount))
 	equals(t, defaultWalletID, wallet.ID)
 	equals(t, defaultMobileHash, wallet.MobileHash)
+	equals(t, float64(210000000-DefaultRegistrationAmount), f.treasure().Balance)
 }
 
 func TestGetWallet(t *testing.T) {
 	t.Log("Test getWallet")
-	response := stub.MockInvoke("1", [][]byte{[]byte("getWallet"), []byte(defaultWalletID)})
-	equals(t, int32(200), response.GetStatus())
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, DefaultRegistrationAmount).build()
 
-	var wallet = new(Wallet)
-	err := json.Unmarshal(response.GetPayload(), wallet)
-	ok(t, err)
+	wallet := f.wallet(defaultWalletID)
 	equals(t, DefaultRegistrationAmount, int(wallet.Amount))
 	equals(t, defaultWalletID, wallet.ID)
 	equals(t, defaultMobileHash, wallet.MobileHash)
@@ -38,19 +35,11 @@ func TestGetWallet(t *testing.T) {
 
 func TestPurchaseCoins(t *testing.T) {
 	t.Log("Test purchaseCoins")
-	response := stub.MockInvoke("1", [][]byte{[]byte("purchaseCoins"),
-		[]byte(defaultWalletID),
-		[]byte("200"),
-		[]byte("MAGIC_BOX"),
-		[]byte("BOX_NUMBER_1")})
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, DefaultRegistrationAmount).build()
+	response := f.invoke("purchaseCoins", defaultWalletID, "200", "MAGIC_BOX", "BOX_NUMBER_1")
 	equals(t, int32(200), response.GetStatus())
 
-	response = stub.MockInvoke("1", [][]byte{[]byte("getWallet"), []byte(defaultWalletID)})
-	equals(t, int32(200), response.GetStatus())
-
-	var wallet = new(Wallet)
-	err := json.Unmarshal(response.GetPayload(), wallet)
-	ok(t, err)
+	wallet := f.wallet(defaultWalletID)
 	equals(t, 310, int(wallet.Amount))
 	equals(t, defaultWalletID, wallet.ID)
 	equals(t, defaultMobileHash, wallet.MobileHash)
@@ -58,61 +47,57 @@ func TestPurchaseCoins(t *testing.T) {
 
 func TestSpendCoins(t *testing.T) {
 	t.Log("Test spendCoins")
-	response := stub.MockInvoke("1", [][]byte{[]byte("spendCoins"),
-		[]byte(defaultWalletID),
-		[]byte("200"),
-		[]byte("PREDICTION"),
-		[]byte("P_NUMBER_1")})
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, 310).build()
+	response := f.invoke("spendCoins", defaultWalletID, "200", "PREDICTION", "P_NUMBER_1")
 	equals(t, int32(200), response.GetStatus())
 
-	response = stub.MockInvoke("1", [][]byte{[]byte("getWallet"), []byte(defaultWalletID)})
-	equals(t, int32(200), response.GetStatus())
-
-	var wallet = new(Wallet)
-	err := json.Unmarshal(response.GetPayload(), wallet)
-	ok(t, err)
+	wallet := f.wallet(defaultWalletID)
 	equals(t, DefaultRegistrationAmount, int(wallet.Amount))
 	equals(t, defaultWalletID, wallet.ID)
 	equals(t, defaultMobileHash, wallet.MobileHash)
 }
 
+func TestUpdateWalletMobileHash(t *testing.T) {
+	t.Log("Test updateWalletMobileHash")
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, DefaultRegistrationAmount).build()
+	response := f.invoke("updateWalletMobileHash", defaultWalletID, "new_mobile_hash")
+	equals(t, int32(200), response.GetStatus())
+
+	wallet := f.wallet(defaultWalletID)
+	equals(t, "new_mobile_hash", wallet.MobileHash)
+	equals(t, DefaultRegistrationAmount, int(wallet.Amount))
+}
+
 // ------------------------------------- Negative Cases --------------------------------------------------------
 
 func TestPurchaseCoinsNegative(t *testing.T) {
 	t.Log("Test purchaseCoins Negative")
-	response := stub.MockInvoke("1", [][]byte{[]byte("purchaseCoins"),
-		[]byte(defaultWalletID),
-		[]byte("200"),
-		[]byte("MAGIC_BOX"),
-		[]byte("BOX_NUMBER_1")})
-	equals(t, int32(409), response.GetStatus())
-
-	response = stub.MockInvoke("1", [][]byte{[]byte("getWallet"), []byte(defaultWalletID)})
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, DefaultRegistrationAmount).build()
+	response := f.invoke("purchaseCoins", defaultWalletID, "200", "MAGIC_BOX", "BOX_NUMBER_1")
 	equals(t, int32(200), response.GetStatus())
 
-	var wallet = new(Wallet)
-	err := json.Unmarshal(response.GetPayload(), wallet)
-	ok(t, err)
-	equals(t, DefaultRegistrationAmount, int(wallet.Amount))
+	response = f.invoke("purchaseCoins", defaultWalletID, "200", "MAGIC_BOX", "BOX_NUMBER_1")
+	equals(t, int32(409), response.GetStatus())
+
+	wallet := f.wallet(defaultWalletID)
+	equals(t, 310, int(wallet.Amount))
 	equals(t, defaultWalletID, wallet.ID)
 	equals(t, defaultMobileHash, wallet.MobileHash)
 }
 
 func TestSpendCoinsNegative(t *testing.T) {
 	t.Log("Test spendCoins Negative")
-	response := stub.MockInvoke("1", [][]byte{[]byte("spendCoins"),
-		[]byte(defaultWalletID),
-		[]byte("50"),
-		[]byte("PREDICTION"),
-		[]byte("P_NUMBER_1")})
+	f := newFixture(t).withWallet(defaultWalletID, defaultMobileHash, 310).build()
+	response := f.invoke("spendCoins", defaultWalletID, "200", "PREDICTION", "P_NUMBER_1")
+	equals(t, int32(200), response.GetStatus())
+
+	response = f.invoke("spendCoins", defaultWalletID, "50", "PREDICTION", "P_NUMBER_1")
 	equals(t, int32(409), response.GetStatus())
 
-	response = stub.MockInvoke("1", [][]byte{[]byte("getWallet"), []byte(defaultWalletID)})
-	equals(t, int32(200), response.GetStatus())
+	response = f.invoke("spendCoins", defaultWalletID, "1000", "PREDICTION", "P_NUMBER_2")
+	equals(t, int32(402), response.GetStatus())
 
-	var wallet = new(Wallet)
-	err := json.Unmarshal(response.GetPayload(), wallet)
-	ok(t, err)
+	wallet := f.wallet(defaultWalletID)
 	equals(t, DefaultRegistrationAmount, int(wallet.Amount))
 	equals(t, defaultWalletID, wallet.ID)
 	equals(t, defaultMobileHash, wallet.MobileHash)
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+)
+
+// OptionsRequest sets the options of a customer. Registration is always
+// set; the other settings keep their stored value when they are zero.
+type OptionsRequest struct {
+	Customer          string
+	Registration      float64
+	MaxSupply         float64
+	ApprovalLimit     float64
+	ApprovalThreshold int
+	AdminMSPs         []string
+	ProposalTTL       int64
+	// StateDatabase is couchdb, leveldb or auto
+	StateDatabase string
+}
+
+func (c *Client) SetOptions(ctx context.Context, req OptionsRequest) (*Options, error) {
+	// setOptions only takes the settings by name
+	args, err := json.Marshal(struct {
+		Registration      float64  `json:"registration"`
+		Customer          string   `json:"customer"`
+		MaxSupply         float64  `json:"maxSupply,omitempty"`
+		ApprovalLimit     float64  `json:"approvalLimit,omitempty"`
+		ApprovalThreshold int      `json:"approvalThreshold,omitempty"`
+		AdminMSPs         []string `json:"adminMSPs,omitempty"`
+		ProposalTTL       int64    `json:"proposalTTL,omitempty"`
+		StateDatabase     string   `json:"stateDatabase,omitempty"`
+	}{req.Registration, orDefault(req.Customer, chaincode.DefaultCustomer), req.MaxSupply, req.ApprovalLimit,
+		req.ApprovalThreshold, req.AdminMSPs, req.ProposalTTL, req.StateDatabase})
+	if err != nil {
+		return nil, err
+	}
+
+	var options = new(Options)
+	err = c.submit(ctx, options, "setOptions", string(args))
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// GetOptions returns the options of a customer, or of the default customer
+// when the customer has none.
+func (c *Client) GetOptions(ctx context.Context, customer string) (*Options, error) {
+	var options = new(Options)
+	err := c.evaluate(ctx, options, "getOptions", orDefault(customer, chaincode.DefaultCustomer))
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// SetEndorsementPolicy requires endorsements by orgs for changes of a
+// wallet, the treasury or the options of a customer. No orgs remove the
+// key-level policy.
+func (c *Client) SetEndorsementPolicy(ctx context.Context, target, id string, orgs ...string) (*EndorsementPolicy, error) {
+	var policy = new(EndorsementPolicy)
+	err := c.submit(ctx, policy, "setEndorsementPolicy", append([]string{target, id}, orgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (c *Client) GetEndorsementPolicy(ctx context.Context, target, id string) (*EndorsementPolicy, error) {
+	var policy = new(EndorsementPolicy)
+	err := c.evaluate(ctx, policy, "getEndorsementPolicy", target, id)
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// MigrationProgress is the progress of one page of documents. Bookmark
+// continues with the next page until Done is set.
+type MigrationProgress struct {
+	chaincode.MigrationProgress
+	Bookmark string
+	Done     bool
+}
+
+// Migrate upgrades one page of documents of docType. Pass the bookmark of
+// the returned progress to continue until it is done. The page is listed in
+// a query of its own, since peers refuse writes after a paginated query.
+func (c *Client) Migrate(ctx context.Context, docType, bookmark string, pageSize int) (*MigrationProgress, error) {
+	size := ""
+	if pageSize > 0 {
+		size = strconv.Itoa(pageSize)
+	}
+
+	var page = new(MigrationPage)
+	err := c.evaluate(ctx, page, "getMigrationPage", docType, bookmark, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var progress = new(MigrationProgress)
+	err = c.submit(ctx, &progress.MigrationProgress, "migrate", append([]string{docType}, page.Keys...)...)
+	if err != nil {
+		return nil, err
+	}
+	progress.Bookmark = page.Bookmark
+	progress.Done = page.Done
+	return progress, nil
+}
+
+// SetMobileIndexKey sets the secret key of the private mobile index. It can
+// only be set once.
+func (c *Client) SetMobileIndexKey(ctx context.Context, indexKey []byte) error {
+	return c.submitTransient(ctx, nil, map[string][]byte{chaincode.TransientIndexKey: indexKey}, "setMobileIndexKey")
+}
+
+// PurgeWalletMobile erases the mobile of a wallet, for erasure requests.
+func (c *Client) PurgeWalletMobile(ctx context.Context, id string) (*Wallet, error) {
+	var wallet = new(Wallet)
+	err := c.submit(ctx, wallet, "purgeWalletMobile", id)
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// PurgeLegacyMobileHashes purges the legacy mobile hashes of one page of
+// wallets, like Migrate. Migrated counts the purged wallets.
+func (c *Client) PurgeLegacyMobileHashes(ctx context.Context, bookmark string, pageSize int) (*MigrationProgress, error) {
+	size := ""
+	if pageSize > 0 {
+		size = strconv.Itoa(pageSize)
+	}
+
+	var page = new(MigrationPage)
+	err := c.evaluate(ctx, page, "getMigrationPage", chaincode.WalletObjectType, bookmark, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var progress = new(MigrationProgress)
+	err = c.submit(ctx, &progress.MigrationProgress, "purgeLegacyMobileHashes", page.Keys...)
+	if err != nil {
+		return nil, err
+	}
+	progress.Bookmark = page.Bookmark
+	progress.Done = page.Done
+	return progress, nil
+}
+
+// RecoveryRequest moves the balance of WalletID to SuccessorID, which is
+// created when it does not exist. Reason is one of the chaincode.Recovery
+// reason codes.
+type RecoveryRequest struct {
+	WalletID    string
+	SuccessorID string
+	Reason      string
+	Customer    string
+}
+
+// RecoverWallet returns the closed wallet.
+func (c *Client) RecoverWallet(ctx context.Context, req RecoveryRequest) (*Wallet, error) {
+	var wallet = new(Wallet)
+	err := c.submit(ctx, wallet, "recoverWallet", req.WalletID, req.SuccessorID, req.Reason,
+		orDefault(req.Customer, chaincode.DefaultCustomer))
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// PauseRequest pauses the functions that write to the ledger. An empty
+// Customer pauses the whole contract and empty Functions every function.
+type PauseRequest struct {
+	Customer  string
+	Functions []string
+	Reason    string
+}
+
+// PauseContract pauses the contract. A pause of the same customer in force is
+// never narrowed: the functions are added to it.
+func (c *Client) PauseContract(ctx context.Context, req PauseRequest) (*Pause, error) {
+	var pause = new(Pause)
+	err := c.submit(ctx, pause, "pauseContract", req.Customer, strings.Join(req.Functions, ","), req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return pause, nil
+}
+
+// UnpauseContract lifts the pause of a customer, or of the whole contract
+// when customer is empty.
+func (c *Client) UnpauseContract(ctx context.Context, customer string) error {
+	return c.submit(ctx, nil, "unpauseContract", customer)
+}
+
+// GetPauses returns the pauses in force.
+func (c *Client) GetPauses(ctx context.Context) ([]Pause, error) {
+	var pauses []Pause
+	err := c.evaluate(ctx, &pauses, "getPauses")
+	if err != nil {
+		return nil, err
+	}
+	return pauses, nil
+}
+
+// Describe returns the version, functions and documents of the deployed
+// chaincode.
+func (c *Client) Describe(ctx context.Context) (*Description, error) {
+	var description = new(Description)
+	err := c.evaluate(ctx, description, "describe")
+	if err != nil {
+		return nil, err
+	}
+	return description, nil
+}
@@ -0,0 +1,135 @@
+// Package client calls the ninjastack chaincode with typed requests instead
+// of positional string arguments. A Transport carries the calls, either to a
+// Fabric network or to a chaincode hosted in-process for tests.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+)
+
+// The documents returned by the chaincode.
+type (
+	Wallet              = chaincode.Wallet
+	WalletTransaction   = chaincode.WalletTransaction
+	Treasure            = chaincode.Treasure
+	TreasureTransaction = chaincode.TreasureTransaction
+	TreasuryProposal    = chaincode.TreasuryProposal
+	Options             = chaincode.Options
+	EndorsementPolicy   = chaincode.EndorsementPolicy
+	MigrationPage       = chaincode.MigrationPage
+	Description         = chaincode.Description
+	Pause               = chaincode.Pause
+	WalletNonce         = chaincode.WalletNonce
+	OperationEnvelope   = chaincode.OperationEnvelope
+)
+
+// Response is the response of the chaincode to one call.
+type Response struct {
+	Status  int32
+	Message string
+	Payload []byte
+}
+
+// Transport calls a chaincode function. Submit commits the transaction,
+// Evaluate only reads. A chaincode error is returned as a Response with its
+// status; the error is reserved for failures of the transport itself.
+type Transport interface {
+	Submit(ctx context.Context, function string, args ...string) (*Response, error)
+	Evaluate(ctx context.Context, function string, args ...string) (*Response, error)
+}
+
+// TransientTransport is a Transport that can also pass a transient map,
+// which reaches the endorsing peers but is not written to the blocks. The
+// calls that carry a mobile identifier require it.
+type TransientTransport interface {
+	Transport
+	SubmitTransient(ctx context.Context, function string, transient map[string][]byte, args ...string) (*Response, error)
+	EvaluateTransient(ctx context.Context, function string, transient map[string][]byte, args ...string) (*Response, error)
+}
+
+// ErrNoTransient is returned by the calls that carry a mobile identifier
+// when the transport is not a TransientTransport.
+var ErrNoTransient = errors.New("client: the transport cannot pass transient data")
+
+type Client struct {
+	transport Transport
+}
+
+func New(transport Transport) *Client {
+	return &Client{transport: transport}
+}
+
+func (c *Client) submit(ctx context.Context, result interface{}, function string, args ...string) error {
+	response, err := c.transport.Submit(ctx, function, args...)
+	return decodeResponse(function, response, err, result)
+}
+
+func (c *Client) evaluate(ctx context.Context, result interface{}, function string, args ...string) error {
+	response, err := c.transport.Evaluate(ctx, function, args...)
+	return decodeResponse(function, response, err, result)
+}
+
+func (c *Client) submitTransient(ctx context.Context, result interface{}, transient map[string][]byte, function string, args ...string) error {
+	transport, isTransient := c.transport.(TransientTransport)
+	if !isTransient {
+		return ErrNoTransient
+	}
+	response, err := transport.SubmitTransient(ctx, function, transient, args...)
+	return decodeResponse(function, response, err, result)
+}
+
+func (c *Client) evaluateTransient(ctx context.Context, result interface{}, transient map[string][]byte, function string, args ...string) error {
+	transport, isTransient := c.transport.(TransientTransport)
+	if !isTransient {
+		return ErrNoTransient
+	}
+	response, err := transport.EvaluateTransient(ctx, function, transient, args...)
+	return decodeResponse(function, response, err, result)
+}
+
+// decodeResponse unmarshals the payload of a successful response into
+// result, unless result is nil.
+func decodeResponse(function string, response *Response, err error, result interface{}) error {
+	if err != nil {
+		return err
+	}
+
+	if response.Status >= 400 {
+		return newError(function, response)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if len(response.Payload) == 0 {
+		return &Error{Function: function, Status: response.Status, Message: "empty response"}
+	}
+	return json.Unmarshal(response.Payload, result)
+}
+
+// formatAmount formats an amount the way the chaincode parses it. A nil
+// amount is left empty, so the chaincode uses its default.
+func formatAmount(amount *float64) string {
+	if amount == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*amount, 'f', -1, 64)
+}
+
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// Float returns a pointer to amount, for the optional amounts of requests.
+func Float(amount float64) *float64 {
+	return &amount
+}
@@ -0,0 +1,417 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+	"github.com/ninjastack101/hyperladger-chaincode/devstub"
+)
+
+func newTestClient(t *testing.T) *Client {
+	stub := devstub.New("ninjastack", new(chaincode.SmartContract))
+	err := stub.SetIdentity("Org1MSP", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, _ := stub.Init([]string{`{"treasury":1000}`})
+	if response.GetStatus() != 200 {
+		t.Fatalf("init returned %d: %s", response.GetStatus(), response.GetMessage())
+	}
+	return New(NewStubTransport(stub))
+}
+
+func TestWalletFlow(t *testing.T) {
+	t.Log("Test creating a wallet and moving coins with typed requests")
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	wallet, err := c.CreateWallet(ctx, CreateWalletRequest{ID: "w1", MobileHash: "hash1", Amount: Float(100), Action: "registration", ActionEntityID: "w1"})
+	if err != nil || wallet.Amount != 100 || wallet.MobileHash != "hash1" {
+		t.Fatalf("CreateWallet returned %v, %v", wallet, err)
+	}
+
+	err = c.PurchaseCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 50, Action: "order", ActionEntityID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.SpendCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 20, Action: "order", ActionEntityID: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wallet, err = c.GetWallet(ctx, "w1")
+	if err != nil || wallet.Amount != 130 {
+		t.Fatalf("GetWallet returned %v, %v", wallet, err)
+	}
+
+	wallet, err = c.GetWalletByMobileHash(ctx, "hash1")
+	if err != nil || wallet.ID != "w1" {
+		t.Fatalf("GetWalletByMobileHash returned %v, %v", wallet, err)
+	}
+
+	treasure, err := c.GetTreasure(ctx)
+	if err != nil || treasure.Balance != 870 {
+		t.Fatalf("GetTreasure returned %v, %v", treasure, err)
+	}
+
+	transactions, err := c.SearchWalletTransactions(ctx, SearchRequest{Filter: map[string]interface{}{"walletId": "w1", "action": "order"}})
+	if err != nil || len(transactions) != 2 {
+		t.Fatalf("SearchWalletTransactions returned %v, %v", transactions, err)
+	}
+
+	wallet, err = c.CreateWallet(ctx, CreateWalletRequest{ID: "w2", MobileHash: "hash2"})
+	if err != nil || wallet.Amount != chaincode.DefaultRegistrationAmount {
+		t.Fatalf("CreateWallet without an amount returned %v, %v", wallet, err)
+	}
+
+	wallet, err = c.RecoverWallet(ctx, RecoveryRequest{WalletID: "w1", SuccessorID: "w3", Reason: chaincode.RecoveryLostAccess})
+	if err != nil || !wallet.Closed || wallet.SuccessorID != "w3" {
+		t.Fatalf("RecoverWallet returned %v, %v", wallet, err)
+	}
+
+	wallet, err = c.GetWallet(ctx, "w3")
+	if err != nil || wallet.Amount != 130 {
+		t.Fatalf("GetWallet of the successor returned %v, %v", wallet, err)
+	}
+
+	description, err := c.Describe(ctx)
+	if err != nil || description.Version != chaincode.Version || len(description.Functions) == 0 {
+		t.Fatalf("Describe returned %v, %v", description, err)
+	}
+}
+
+func TestSignedSpend(t *testing.T) {
+	t.Log("Test spending from a wallet with a public key")
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	key := ed25519.NewKeyFromSeed([]byte("0123456789abcdef0123456789abcdef"))
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.CreateWallet(ctx, CreateWalletRequest{ID: "w1", MobileHash: "hash1", Amount: Float(100), PublicKey: base64.StdEncoding.EncodeToString(der)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := CoinsRequest{WalletID: "w1", Amount: 20, Action: "order", ActionEntityID: "1"}
+	err = c.SpendCoins(ctx, req)
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("unsigned SpendCoins returned %v", err)
+	}
+
+	walletNonce, err := c.GetWalletNonce(ctx, "w1")
+	if err != nil || walletNonce.Nonce != 0 {
+		t.Fatalf("GetWalletNonce returned %v, %v", walletNonce, err)
+	}
+
+	expiry := time.Now().Add(time.Hour).Unix()
+	payload := chaincode.OperationPayload("spendCoins", "w1", "20", "order", "1", "1", strconv.FormatInt(expiry, 10))
+	req.Signature = &Signature{Nonce: 1, Expiry: expiry, Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))}
+	err = c.SpendCoins(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wallet, err := c.GetWallet(ctx, "w1")
+	if err != nil || wallet.Amount != 80 {
+		t.Fatalf("GetWallet returned %v, %v", wallet, err)
+	}
+
+	// The nonce of a replayed request has been used
+	err = c.SpendCoins(ctx, req)
+	if !errors.Is(err, ErrDoubleHit) {
+		t.Fatalf("replayed SpendCoins returned %v", err)
+	}
+
+	payload = chaincode.OperationPayload("spendCoins", "w1", "30", "order", "3", "2", strconv.FormatInt(expiry, 10))
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	err = c.ExecuteSigned(ctx, RelayRequest{Operation: OperationEnvelope{
+		Function: "spendCoins",
+		Args:     []byte(`["w1","30","order","3","","2","` + strconv.FormatInt(expiry, 10) + `","` + signature + `"]`),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wallet, err = c.GetWallet(ctx, "w1")
+	if err != nil || wallet.Amount != 50 {
+		t.Fatalf("GetWallet after ExecuteSigned returned %v, %v", wallet, err)
+	}
+}
+
+func TestSponsoredRelay(t *testing.T) {
+	t.Log("Test relaying an operation with a relay fee authorized by the sponsor")
+	ctx := context.Background()
+	stub := devstub.New("ninjastack", new(chaincode.SmartContract))
+	err := stub.SetIdentity("Org1MSP", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, _ := stub.Init([]string{`{"treasury":1000}`})
+	if response.GetStatus() != 200 {
+		t.Fatalf("init returned %d: %s", response.GetStatus(), response.GetMessage())
+	}
+	c := New(NewStubTransport(stub))
+
+	key := ed25519.NewKeyFromSeed([]byte("0123456789abcdef0123456789abcdef"))
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := base64.StdEncoding.EncodeToString(der)
+
+	for _, req := range []CreateWalletRequest{
+		{ID: "w1", MobileHash: "hash1", Amount: Float(100), PublicKey: publicKey},
+		{ID: "sponsor", MobileHash: "hash2", Amount: Float(10), PublicKey: publicKey},
+		{ID: "relayer", MobileHash: "hash3", Amount: Float(0)},
+	} {
+		_, err = c.CreateWallet(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expiry := time.Now().Add(time.Hour).Unix()
+	payload := chaincode.OperationPayload("spendCoins", "w1", "30", "order", "1", "1", strconv.FormatInt(expiry, 10))
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	req := RelayRequest{
+		Operation: OperationEnvelope{
+			Function: "spendCoins",
+			Args:     []byte(`["w1","30","order","1","","1","` + strconv.FormatInt(expiry, 10) + `","` + signature + `"]`),
+		},
+		SponsorWalletID: "sponsor",
+		RelayFee:        2,
+		RelayerWalletID: "relayer",
+	}
+
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientID, err := cid.GetID(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err = SponsorPayload(req, mspID, clientID, 1, expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SponsorSignature = &Signature{Nonce: 1, Expiry: expiry, Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))}
+
+	err = c.ExecuteSigned(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, amount := range map[string]float64{"w1": 70, "sponsor": 8, "relayer": 2} {
+		wallet, err := c.GetWallet(ctx, id)
+		if err != nil || wallet.Amount != amount {
+			t.Fatalf("GetWallet(%s) after ExecuteSigned returned %v, %v", id, wallet, err)
+		}
+	}
+}
+
+func TestWalletMobile(t *testing.T) {
+	t.Log("Test passing mobiles in the transient map")
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	err := c.SetMobileIndexKey(ctx, []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wallet, err := c.CreateWallet(ctx, CreateWalletRequest{ID: "w1", Mobile: "+4915112345678"})
+	if err != nil || wallet.MobileHash == "" {
+		t.Fatalf("CreateWallet returned %v, %v", wallet, err)
+	}
+
+	wallet, err = c.GetWalletByMobile(ctx, "+4915112345678")
+	if err != nil || wallet.ID != "w1" {
+		t.Fatalf("GetWalletByMobile returned %v, %v", wallet, err)
+	}
+
+	_, err = c.UpdateWalletMobile(ctx, "w1", "+4915187654321")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.GetWalletByMobile(ctx, "+4915112345678")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetWalletByMobile of the previous mobile returned %v", err)
+	}
+
+	_, err = c.CreateWallet(ctx, CreateWalletRequest{ID: "w2", MobileHash: "hash2"})
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("CreateWallet with a mobile hash returned %v", err)
+	}
+
+	wallet, err = c.PurgeWalletMobile(ctx, "w1")
+	if err != nil || wallet.MobileHash != "" {
+		t.Fatalf("PurgeWalletMobile returned %v, %v", wallet, err)
+	}
+
+	// A gateway contract without transient data cannot pass mobiles
+	_, err = New(NewContractTransport(new(fakeContract))).GetWalletByMobile(ctx, "+4915112345678")
+	if !errors.Is(err, ErrNoTransient) {
+		t.Fatalf("GetWalletByMobile without transient data returned %v", err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Log("Test migrating the wallets page by page")
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	for _, id := range []string{"w1", "w2", "w3"} {
+		_, err := c.CreateWallet(ctx, CreateWalletRequest{ID: id, MobileHash: "hash_" + id})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var progress = new(MigrationProgress)
+	scanned := 0
+	for pages := 1; !progress.Done; pages++ {
+		var err error
+		progress, err = c.Migrate(ctx, chaincode.WalletObjectType, progress.Bookmark, 2)
+		if err != nil || pages > 2 {
+			t.Fatalf("Migrate returned %v, %v on page %d", progress, err, pages)
+		}
+		scanned += progress.Scanned
+	}
+
+	if scanned != 3 {
+		t.Fatalf("Migrate scanned %d wallets", scanned)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	t.Log("Test chaincode errors map to typed errors")
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	_, err := c.CreateWallet(ctx, CreateWalletRequest{ID: "w1", MobileHash: "hash1", Amount: Float(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		kind error
+	}{
+		{"double hit", func() error {
+			c.PurchaseCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 1, Action: "order", ActionEntityID: "1"})
+			return c.PurchaseCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 1, Action: "order", ActionEntityID: "1"})
+		}, ErrDoubleHit},
+		{"wallet funds", func() error {
+			return c.SpendCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 1000, Action: "order", ActionEntityID: "2"})
+		}, ErrInsufficientFunds},
+		{"treasury funds", func() error {
+			return c.PurchaseCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 10000, Action: "order", ActionEntityID: "3"})
+		}, ErrTreasuryExhausted},
+		{"unknown wallet", func() error {
+			_, err := c.GetWallet(ctx, "unknown")
+			return err
+		}, ErrNotFound},
+		{"existing wallet", func() error {
+			_, err := c.CreateWallet(ctx, CreateWalletRequest{ID: "w1", MobileHash: "hash1"})
+			return err
+		}, ErrAlreadyExists},
+		{"used mobile hash", func() error {
+			_, err := c.CreateWallet(ctx, CreateWalletRequest{ID: "w2", MobileHash: "hash1"})
+			return err
+		}, ErrAlreadyExists},
+		{"paused", func() error {
+			_, err := c.PauseContract(ctx, PauseRequest{Functions: []string{"spendCoins"}, Reason: "incident"})
+			if err != nil {
+				return err
+			}
+			defer c.UnpauseContract(ctx, "")
+			return c.SpendCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 1, Action: "order", ActionEntityID: "5"})
+		}, ErrPaused},
+	}
+
+	for _, test := range tests {
+		err := test.call()
+		if !errors.Is(err, test.kind) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.kind, err)
+		}
+	}
+
+	// Errors keep their status, code and message
+	err = c.SpendCoins(ctx, CoinsRequest{WalletID: "w1", Amount: -1, Action: "order", ActionEntityID: "4"})
+	var chaincodeErr *Error
+	if !errors.As(err, &chaincodeErr) || chaincodeErr.Status != 400 || chaincodeErr.Code != chaincode.CodeInvalidArgument ||
+		chaincodeErr.Function != "spendCoins" || chaincodeErr.Message != "amount must not be negative" || !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("unexpected error %#v", err)
+	}
+}
+
+type fakeContract struct {
+	name string
+	args []string
+	err  error
+}
+
+func (c *fakeContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	c.name, c.args = name, args
+	return []byte(`{"id":"w1","amount":110}`), c.err
+}
+
+func (c *fakeContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return c.SubmitTransaction(name, args...)
+}
+
+func TestContractTransport(t *testing.T) {
+	t.Log("Test calls and errors through a Fabric gateway contract")
+	ctx := context.Background()
+	contract := new(fakeContract)
+	c := New(NewContractTransport(contract))
+
+	_, err := c.CreateWallet(ctx, CreateWalletRequest{ID: "w1", MobileHash: "hash1", Customer: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"w1", "hash1", "", chaincode.DefaultAction, chaincode.DefaultActionEntityId, "acme"}
+	if contract.name != "createWallet" || !reflect.DeepEqual(expected, contract.args) {
+		t.Fatalf("unexpected call %s%q", contract.name, contract.args)
+	}
+
+	// ---- Negative Cases ----
+	contract.err = errors.New("Transaction processing for endorser [peer0:7051]: Chaincode status Code: (409) UNKNOWN. Description: Transaction with given action and action entity already exists for the given wallet id")
+	err = c.PurchaseCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 1, Action: "order", ActionEntityID: "1"})
+	if !errors.Is(err, ErrDoubleHit) {
+		t.Fatalf("expected a double hit, got %v", err)
+	}
+
+	contract.err = errors.New("Transaction processing for endorser [peer0:7051]: Chaincode status Code: (422) UNKNOWN. Description: insufficient funds on treasure")
+	err = c.PurchaseCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 1, Action: "order", ActionEntityID: "2"})
+	if !errors.Is(err, ErrTreasuryExhausted) {
+		t.Fatalf("expected an exhausted treasury, got %v", err)
+	}
+
+	contract.err = errors.New("connection refused")
+	err = c.PurchaseCoins(ctx, CoinsRequest{WalletID: "w1", Amount: 1, Action: "order", ActionEntityID: "1"})
+	if err != contract.err {
+		t.Fatalf("expected the transport error, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+)
+
+// Errors of the chaincode, to be checked with errors.Is.
+var (
+	ErrDoubleHit         = errors.New("transaction already exists for the action and action entity")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrTreasuryExhausted = errors.New("insufficient funds on the treasury")
+	ErrNotFound          = errors.New("not found")
+	ErrAlreadyExists     = errors.New("already exists")
+	ErrForbidden         = errors.New("invoker is not allowed to call the function")
+	ErrInvalidArgument   = errors.New("invalid argument")
+	ErrPaused            = errors.New("contract is paused")
+)
+
+var errorKinds = map[chaincode.ErrorCode]error{
+	chaincode.CodeDoubleHit:         ErrDoubleHit,
+	chaincode.CodeInsufficientFunds: ErrInsufficientFunds,
+	chaincode.CodeTreasuryExhausted: ErrTreasuryExhausted,
+	chaincode.CodeNotFound:          ErrNotFound,
+	chaincode.CodeAlreadyExists:     ErrAlreadyExists,
+	chaincode.CodeForbidden:         ErrForbidden,
+	chaincode.CodeInvalidArgument:   ErrInvalidArgument,
+	chaincode.CodePaused:            ErrPaused,
+}
+
+// Error is a chaincode error response.
+type Error struct {
+	Function string
+	Status   int32
+	Code     chaincode.ErrorCode
+	Message  string
+	kind     error
+}
+
+func (e *Error) Error() string {
+	return e.Function + ": " + e.Message
+}
+
+// Unwrap returns the sentinel error the response maps to, if any.
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+// newError maps a response to a sentinel error. The code is read from the
+// JSON error payload, or from the status when the transport only reports the
+// status and message, as Fabric SDKs do for a failed endorsement.
+func newError(function string, response *Response) *Error {
+	err := &Error{Function: function, Status: response.Status, Message: response.Message}
+
+	var payload chaincode.Error
+	if json.Unmarshal(response.Payload, &payload) == nil && payload.Code != "" {
+		err.Code = payload.Code
+	} else {
+		err.Code, _ = chaincode.ErrorCodeOf(response.Status)
+	}
+
+	err.kind = errorKinds[err.Code]
+	return err
+}
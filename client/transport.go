@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"github.com/ninjastack101/hyperladger-chaincode/devstub"
+)
+
+// StubTransport calls a chaincode hosted in-process on a devstub, for tests.
+type StubTransport struct {
+	Stub *devstub.Stub
+}
+
+func NewStubTransport(stub *devstub.Stub) *StubTransport {
+	return &StubTransport{Stub: stub}
+}
+
+func (t *StubTransport) Submit(ctx context.Context, function string, args ...string) (*Response, error) {
+	response, _ := t.Stub.Invoke(append([]string{function}, args...))
+	return &Response{Status: response.GetStatus(), Message: response.GetMessage(), Payload: response.GetPayload()}, nil
+}
+
+func (t *StubTransport) Evaluate(ctx context.Context, function string, args ...string) (*Response, error) {
+	response, _ := t.Stub.Query(append([]string{function}, args...))
+	return &Response{Status: response.GetStatus(), Message: response.GetMessage(), Payload: response.GetPayload()}, nil
+}
+
+func (t *StubTransport) SubmitTransient(ctx context.Context, function string, transient map[string][]byte, args ...string) (*Response, error) {
+	t.Stub.SetTransient(transient)
+	return t.Submit(ctx, function, args...)
+}
+
+func (t *StubTransport) EvaluateTransient(ctx context.Context, function string, transient map[string][]byte, args ...string) (*Response, error) {
+	t.Stub.SetTransient(transient)
+	return t.Evaluate(ctx, function, args...)
+}
+
+// Contract is the contract of a Fabric gateway, such as the Contract of the
+// gateway package of fabric-sdk-go.
+type Contract interface {
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+// TransientContract is a Contract that can pass a transient map, such as an
+// adapter over the transactions of the gateway package of fabric-sdk-go
+// with WithTransient.
+type TransientContract interface {
+	Contract
+	SubmitTransientTransaction(name string, transient map[string][]byte, args ...string) ([]byte, error)
+	EvaluateTransientTransaction(name string, transient map[string][]byte, args ...string) ([]byte, error)
+}
+
+// ContractTransport calls the chaincode through a Fabric gateway. It passes
+// transient data when its Contract is a TransientContract.
+type ContractTransport struct {
+	Contract Contract
+}
+
+func NewContractTransport(contract Contract) *ContractTransport {
+	return &ContractTransport{Contract: contract}
+}
+
+func (t *ContractTransport) Submit(ctx context.Context, function string, args ...string) (*Response, error) {
+	return contractResponse(t.Contract.SubmitTransaction(function, args...))
+}
+
+func (t *ContractTransport) Evaluate(ctx context.Context, function string, args ...string) (*Response, error) {
+	return contractResponse(t.Contract.EvaluateTransaction(function, args...))
+}
+
+func (t *ContractTransport) SubmitTransient(ctx context.Context, function string, transient map[string][]byte, args ...string) (*Response, error) {
+	contract, isTransient := t.Contract.(TransientContract)
+	if !isTransient {
+		return nil, ErrNoTransient
+	}
+	return contractResponse(contract.SubmitTransientTransaction(function, transient, args...))
+}
+
+func (t *ContractTransport) EvaluateTransient(ctx context.Context, function string, transient map[string][]byte, args ...string) (*Response, error) {
+	contract, isTransient := t.Contract.(TransientContract)
+	if !isTransient {
+		return nil, ErrNoTransient
+	}
+	return contractResponse(contract.EvaluateTransientTransaction(function, transient, args...))
+}
+
+// chaincodeStatus finds the chaincode response in an endorsement error, such
+// as "Chaincode status Code: (409) UNKNOWN. Description: ...".
+var chaincodeStatus = regexp.MustCompile(`Chaincode status Code: \((\d+)\)[^.]*\. Description: (.*)`)
+
+// contractResponse turns a chaincode error reported by the gateway back into
+// a Response, so that it maps to a typed error. Other errors are returned as
+// transport errors.
+func contractResponse(payload []byte, err error) (*Response, error) {
+	if err == nil {
+		return &Response{Status: 200, Payload: payload}, nil
+	}
+
+	match := chaincodeStatus.FindStringSubmatch(err.Error())
+	if match == nil {
+		return nil, err
+	}
+
+	status, convErr := strconv.Atoi(match[1])
+	if convErr != nil {
+		return nil, err
+	}
+	return &Response{Status: int32(status), Message: match[2]}, nil
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+)
+
+// SupplyRequest mints or burns Amount coins.
+type SupplyRequest struct {
+	Amount         float64
+	Action         string
+	ActionEntityID string
+}
+
+// ProposalRequest proposes a treasury operation: mint, burn or withdraw.
+// WalletID is the receiver of a withdraw.
+type ProposalRequest struct {
+	Operation      string
+	Amount         float64
+	Action         string
+	ActionEntityID string
+	WalletID       string
+	Customer       string
+}
+
+// CreateTreasure creates a treasury with balance coins. An empty id creates
+// the default treasury.
+func (c *Client) CreateTreasure(ctx context.Context, id string, balance float64) (*Treasure, error) {
+	var treasure = new(Treasure)
+	err := c.submit(ctx, treasure, "createTreasure", strconv.FormatFloat(balance, 'f', -1, 64), orDefault(id, chaincode.TreasureID))
+	if err != nil {
+		return nil, err
+	}
+	return treasure, nil
+}
+
+// GetTreasure returns the default treasury.
+func (c *Client) GetTreasure(ctx context.Context) (*Treasure, error) {
+	var treasure = new(Treasure)
+	err := c.evaluate(ctx, treasure, "getTreasure")
+	if err != nil {
+		return nil, err
+	}
+	return treasure, nil
+}
+
+func (c *Client) MintCoins(ctx context.Context, req SupplyRequest) (*Treasure, error) {
+	return c.changeSupply(ctx, "mintCoins", req)
+}
+
+func (c *Client) BurnCoins(ctx context.Context, req SupplyRequest) (*Treasure, error) {
+	return c.changeSupply(ctx, "burnCoins", req)
+}
+
+func (c *Client) changeSupply(ctx context.Context, function string, req SupplyRequest) (*Treasure, error) {
+	var treasure = new(Treasure)
+	err := c.submit(ctx, treasure, function, strconv.FormatFloat(req.Amount, 'f', -1, 64), req.Action, req.ActionEntityID)
+	if err != nil {
+		return nil, err
+	}
+	return treasure, nil
+}
+
+func (c *Client) ProposeTreasuryOp(ctx context.Context, req ProposalRequest) (*TreasuryProposal, error) {
+	return c.submitProposal(ctx, "proposeTreasuryOp", req.Operation, strconv.FormatFloat(req.Amount, 'f', -1, 64),
+		req.Action, req.ActionEntityID, req.WalletID, orDefault(req.Customer, chaincode.DefaultCustomer))
+}
+
+func (c *Client) ApproveTreasuryOp(ctx context.Context, id string) (*TreasuryProposal, error) {
+	return c.submitProposal(ctx, "approveTreasuryOp", id)
+}
+
+func (c *Client) ExecuteTreasuryOp(ctx context.Context, id string) (*TreasuryProposal, error) {
+	return c.submitProposal(ctx, "executeTreasuryOp", id)
+}
+
+func (c *Client) GetTreasuryOp(ctx context.Context, id string) (*TreasuryProposal, error) {
+	response, err := c.transport.Evaluate(ctx, "getTreasuryOp", id)
+	if err == nil && response.Status < 400 && len(response.Payload) == 0 {
+		return nil, &Error{Function: "getTreasuryOp", Status: response.Status, Code: chaincode.CodeNotFound, Message: "Proposal with id " + id + " not found", kind: ErrNotFound}
+	}
+
+	var proposal = new(TreasuryProposal)
+	err = decodeResponse("getTreasuryOp", response, err, proposal)
+	if err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+func (c *Client) submitProposal(ctx context.Context, function string, args ...string) (*TreasuryProposal, error) {
+	var proposal = new(TreasuryProposal)
+	err := c.submit(ctx, proposal, function, args...)
+	if err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
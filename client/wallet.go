@@ -0,0 +1,289 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"strconv"
+
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+)
+
+type CreateWalletRequest struct {
+	ID string
+	// Mobile is kept in the private collection of the chaincode; the wallet
+	// only holds a salted commitment to it as its mobile hash.
+	Mobile string
+	// Deprecated: MobileHash is written to the ledger as is and is rejected
+	// once the mobile index key is set. Use Mobile.
+	MobileHash string
+	// Amount is taken from the treasury. Without it the wallet gets the
+	// registration amount of the customer.
+	Amount         *float64
+	Action         string
+	ActionEntityID string
+	Customer       string
+	// PublicKey makes the spends of the wallet require a signature, see
+	// Signature.
+	PublicKey string
+}
+
+// Signature authorizes an operation on a wallet with a public key. It signs
+// the payload returned by chaincode.OperationPayload for the arguments of
+// the operation, the nonce and the expiry, a unix time in seconds. Nonce is
+// the next nonce of the wallet, see GetWalletNonce.
+type Signature struct {
+	Nonce     int64
+	Expiry    int64
+	Signature string
+}
+
+func (s *Signature) args() []string {
+	if s == nil {
+		return nil
+	}
+	return []string{strconv.FormatInt(s.Nonce, 10), strconv.FormatInt(s.Expiry, 10), s.Signature}
+}
+
+// CoinsRequest moves Amount between the treasury and a wallet. Action and
+// ActionEntityID identify the operation; repeating them is a double hit.
+type CoinsRequest struct {
+	WalletID       string
+	Amount         float64
+	Action         string
+	ActionEntityID string
+	Customer       string
+	// Signature is required by spends from wallets with a public key.
+	Signature *Signature
+}
+
+// RelayRequest relays an operation signed by the owner of a wallet. A
+// positive RelayFee moves the fee from SponsorWalletID to RelayerWalletID,
+// authorized by SponsorSignature over the payload of SponsorPayload.
+type RelayRequest struct {
+	Operation        OperationEnvelope
+	SponsorWalletID  string
+	RelayFee         float64
+	RelayerWalletID  string
+	SponsorSignature *Signature
+}
+
+// SponsorPayload returns the payload the sponsor of req signs for the relayer
+// with the given MSP ID and client ID, using the nonce and expiry of its
+// SponsorSignature.
+func SponsorPayload(req RelayRequest, relayerMSPID, relayerID string, nonce, expiry int64) ([]byte, error) {
+	operation, err := json.Marshal(req.Operation)
+	if err != nil {
+		return nil, err
+	}
+	return chaincode.SponsorPayload(string(operation), req.SponsorWalletID, strconv.FormatFloat(req.RelayFee, 'f', -1, 64),
+		req.RelayerWalletID, relayerMSPID, relayerID, strconv.FormatInt(nonce, 10), strconv.FormatInt(expiry, 10)), nil
+}
+
+// SearchRequest filters a search with a CouchDB selector. On LevelDB only
+// equality on mobileHash, walletId, customer and action is supported. Page
+// starts at 1.
+type SearchRequest struct {
+	Filter map[string]interface{}
+	Page   int
+	Size   int
+}
+
+func (r SearchRequest) args() ([]string, error) {
+	filter := ""
+	if len(r.Filter) != 0 {
+		filterBytes, err := json.Marshal(r.Filter)
+		if err != nil {
+			return nil, err
+		}
+		filter = string(filterBytes)
+	}
+
+	page, size := r.Page, r.Size
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 10
+	}
+	return []string{filter, strconv.Itoa(page), strconv.Itoa(size)}, nil
+}
+
+func (c *Client) CreateWallet(ctx context.Context, req CreateWalletRequest) (*Wallet, error) {
+	var wallet = new(Wallet)
+	args := []string{req.ID, req.MobileHash, formatAmount(req.Amount),
+		orDefault(req.Action, chaincode.DefaultAction), orDefault(req.ActionEntityID, chaincode.DefaultActionEntityId),
+		orDefault(req.Customer, chaincode.DefaultCustomer)}
+	if req.PublicKey != "" {
+		args = append(args, req.PublicKey)
+	}
+
+	var err error
+	if req.Mobile != "" {
+		var transient map[string][]byte
+		transient, err = mobileTransient(req.Mobile)
+		if err != nil {
+			return nil, err
+		}
+		err = c.submitTransient(ctx, wallet, transient, "createWallet", args...)
+	} else {
+		err = c.submit(ctx, wallet, "createWallet", args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// mobileTransient returns the transient map that passes a mobile identifier
+// with a new random salt.
+func mobileTransient(mobile string) (map[string][]byte, error) {
+	salt := make([]byte, chaincode.MinMobileSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{chaincode.TransientMobile: []byte(mobile), chaincode.TransientSalt: salt}, nil
+}
+
+// GetWallet returns ErrNotFound for an unknown wallet.
+func (c *Client) GetWallet(ctx context.Context, id string) (*Wallet, error) {
+	response, err := c.transport.Evaluate(ctx, "getWallet", id)
+	if err == nil && response.Status < 400 && len(response.Payload) == 0 {
+		return nil, &Error{Function: "getWallet", Status: response.Status, Code: chaincode.CodeNotFound, Message: "Wallet with id " + id + " not found", kind: ErrNotFound}
+	}
+
+	var wallet = new(Wallet)
+	err = decodeResponse("getWallet", response, err, wallet)
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// GetWalletByMobileHash returns ErrNotFound when no wallet has the mobile
+// hash.
+func (c *Client) GetWalletByMobileHash(ctx context.Context, mobileHash string) (*Wallet, error) {
+	var wallet = new(Wallet)
+	err := c.evaluate(ctx, wallet, "getWalletByMobileHash", mobileHash)
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// GetWalletNonce returns the last nonce used by the signed operations of a
+// wallet. The next signed operation must carry the nonce after it.
+func (c *Client) GetWalletNonce(ctx context.Context, id string) (*WalletNonce, error) {
+	var walletNonce = new(WalletNonce)
+	err := c.evaluate(ctx, walletNonce, "getWalletNonce", id)
+	if err != nil {
+		return nil, err
+	}
+	return walletNonce, nil
+}
+
+// GetWalletByMobile returns ErrNotFound when no wallet has the mobile.
+func (c *Client) GetWalletByMobile(ctx context.Context, mobile string) (*Wallet, error) {
+	var wallet = new(Wallet)
+	transient := map[string][]byte{chaincode.TransientMobile: []byte(mobile)}
+	err := c.evaluateTransient(ctx, wallet, transient, "getWalletByMobileHash")
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// UpdateWalletMobile replaces the mobile of a wallet. It returns
+// ErrAlreadyExists when another wallet has the mobile.
+func (c *Client) UpdateWalletMobile(ctx context.Context, id, mobile string) (*Wallet, error) {
+	transient, err := mobileTransient(mobile)
+	if err != nil {
+		return nil, err
+	}
+
+	var wallet = new(Wallet)
+	err = c.submitTransient(ctx, wallet, transient, "updateWalletMobileHash", id)
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// UpdateWalletMobileHash returns ErrAlreadyExists when another wallet has
+// the mobile hash.
+//
+// Deprecated: the mobile hash is rejected once the mobile index key is set.
+// Use UpdateWalletMobile.
+func (c *Client) UpdateWalletMobileHash(ctx context.Context, id, mobileHash string) (*Wallet, error) {
+	var wallet = new(Wallet)
+	err := c.submit(ctx, wallet, "updateWalletMobileHash", id, mobileHash)
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// PurchaseCoins moves coins from the treasury to a wallet.
+func (c *Client) PurchaseCoins(ctx context.Context, req CoinsRequest) error {
+	return c.submit(ctx, nil, "purchaseCoins", req.WalletID, strconv.FormatFloat(req.Amount, 'f', -1, 64),
+		req.Action, req.ActionEntityID, orDefault(req.Customer, chaincode.DefaultCustomer))
+}
+
+// SpendCoins moves coins from a wallet back to the treasury. It returns
+// ErrForbidden when the wallet has a public key and req.Signature is
+// missing or invalid.
+func (c *Client) SpendCoins(ctx context.Context, req CoinsRequest) error {
+	args := []string{req.WalletID, strconv.FormatFloat(req.Amount, 'f', -1, 64),
+		req.Action, req.ActionEntityID, orDefault(req.Customer, chaincode.DefaultCustomer)}
+	return c.submit(ctx, nil, "spendCoins", append(args, req.Signature.args()...)...)
+}
+
+// RotateWalletKey sets the public key of a wallet. Replacing a key requires
+// a signature by the current key; only admins can set the first key of an
+// existing wallet.
+func (c *Client) RotateWalletKey(ctx context.Context, id, publicKey string, signature *Signature) (*Wallet, error) {
+	var wallet = new(Wallet)
+	err := c.submit(ctx, wallet, "rotateWalletKey", append([]string{id, publicKey}, signature.args()...)...)
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// ExecuteSigned submits a signed operation on behalf of the owner of its
+// wallet, with the identity of the client as the relayer.
+func (c *Client) ExecuteSigned(ctx context.Context, req RelayRequest) error {
+	operation, err := json.Marshal(req.Operation)
+	if err != nil {
+		return err
+	}
+	args := []string{string(operation), req.SponsorWalletID, strconv.FormatFloat(req.RelayFee, 'f', -1, 64), req.RelayerWalletID}
+	return c.submit(ctx, nil, "executeSigned", append(args, req.SponsorSignature.args()...)...)
+}
+
+func (c *Client) SearchWallets(ctx context.Context, req SearchRequest) ([]Wallet, error) {
+	var wallets []Wallet
+	err := c.search(ctx, &wallets, "searchWallets", req)
+	return wallets, err
+}
+
+func (c *Client) SearchWalletTransactions(ctx context.Context, req SearchRequest) ([]WalletTransaction, error) {
+	var transactions []WalletTransaction
+	err := c.search(ctx, &transactions, "searchWalletTransactions", req)
+	return transactions, err
+}
+
+func (c *Client) SearchTreasureTransactions(ctx context.Context, req SearchRequest) ([]TreasureTransaction, error) {
+	var transactions []TreasureTransaction
+	err := c.search(ctx, &transactions, "searchTreasureTransactions", req)
+	return transactions, err
+}
+
+func (c *Client) search(ctx context.Context, result interface{}, function string, req SearchRequest) error {
+	args, err := req.args()
+	if err != nil {
+		return err
+	}
+	return c.evaluate(ctx, result, function, args...)
+}
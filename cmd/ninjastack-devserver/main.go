@@ -0,0 +1,49 @@
+// Command ninjastack-devserver serves the chaincode over a REST API for
+// local development, without a Fabric network. The chaincode runs in-process
+// on a ledger that is kept in memory, or in the -state file.
+//
+// Every invoke function has a route, such as POST /wallets for createWallet,
+// GET /wallets/{id} for getWallet and POST /wallets/{id}/spend for
+// spendCoins; see routes. Requests and responses are JSON. The transient map
+// of a transaction, such as the mobile of a wallet, is the transient field of
+// the request body.
+//
+// GET /events streams Server-Sent Events. Every committed transaction sends a
+// "transaction" event with its txId and function, followed by the chaincode
+// event the function set: an event named after the function with its
+// arguments, sensitive ones redacted. Queries and failed transactions send
+// none. GET /metrics returns the call metrics of every function and GET
+// /describe the description of the chaincode.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+	"github.com/ninjastack101/hyperladger-chaincode/devstub"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	statePath := flag.String("state", "", "file the ledger is kept in, in memory if empty")
+	mspID := flag.String("msp", "Org1MSP", "MSP of requests without an X-MSP-ID header")
+	user := flag.String("user", "admin", "identity of requests without an X-User header")
+	flag.Parse()
+
+	chaincode.SetLogLevel(shim.LogError)
+
+	stub := devstub.New("ninjastack", new(chaincode.SmartContract))
+	if *statePath != "" {
+		err := stub.Load(*statePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	s := &server{stub: stub, statePath: *statePath, mspID: *mspID, user: *user, events: newEventBroker()}
+	log.Printf("Serving the chaincode on http://%s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, s))
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+	"github.com/ninjastack101/hyperladger-chaincode/devstub"
+)
+
+// requestBody holds the JSON fields of every route. Amounts are kept as
+// numbers in their original notation and passed to the chaincode unchanged.
+// Transient is the transient map of the transaction, such as the mobile and
+// salt of a wallet, and is never passed as an argument.
+type requestBody struct {
+	ID             string            `json:"id"`
+	MobileHash     string            `json:"mobileHash"`
+	Amount         json.Number       `json:"amount"`
+	Balance        json.Number       `json:"balance"`
+	Action         string            `json:"action"`
+	ActionEntityID string            `json:"actionEntityId"`
+	Customer       string            `json:"customer"`
+	Operation      json.RawMessage   `json:"operation"`
+	WalletID       string            `json:"walletId"`
+	Orgs           []string          `json:"orgs"`
+	Keys           []string          `json:"keys"`
+	Functions      []string          `json:"functions"`
+	Reason         string            `json:"reason"`
+	PublicKey      string            `json:"publicKey"`
+	Nonce          json.Number       `json:"nonce"`
+	Expiry         json.Number       `json:"expiry"`
+	Signature      string            `json:"signature"`
+	SuccessorID    string            `json:"successorId"`
+	Transient      map[string]string `json:"transient"`
+}
+
+// request is what the arguments of a chaincode function are built from.
+type request struct {
+	params map[string]string
+	query  url.Values
+	body   requestBody
+	raw    []byte
+}
+
+// route maps a REST endpoint to a chaincode function. Path segments in
+// braces are parameters. Routes that only read run as queries and never
+// commit.
+type route struct {
+	method   string
+	pattern  string
+	function string
+	query    bool
+	args     func(r *request) []string
+}
+
+var routes = []route{
+	{"POST", "/init", "init", false, func(r *request) []string {
+		if len(r.raw) == 0 {
+			return nil
+		}
+		return []string{string(r.raw)}
+	}},
+	{"POST", "/wallets", "createWallet", false, func(r *request) []string {
+		return []string{r.body.ID, r.body.MobileHash, r.body.Amount.String(), orDefault(r.body.Action, chaincode.DefaultAction), orDefault(r.body.ActionEntityID, chaincode.DefaultActionEntityId), orDefault(r.body.Customer, chaincode.DefaultCustomer), r.body.PublicKey}
+	}},
+	{"GET", "/wallets", "searchWallets", true, func(r *request) []string {
+		return searchArgs(r)
+	}},
+	{"GET", "/wallets/{id}", "getWallet", true, func(r *request) []string {
+		return []string{r.params["id"]}
+	}},
+	{"GET", "/mobile-hashes/{mobileHash}/wallet", "getWalletByMobileHash", true, func(r *request) []string {
+		return []string{r.params["mobileHash"]}
+	}},
+	// Looks a wallet up by the transient mobile, which a GET has no body for
+	{"POST", "/wallets/lookup", "getWalletByMobileHash", true, func(r *request) []string {
+		return nil
+	}},
+	{"PUT", "/wallets/{id}/mobileHash", "updateWalletMobileHash", false, func(r *request) []string {
+		return []string{r.params["id"], r.body.MobileHash}
+	}},
+	{"DELETE", "/wallets/{id}/mobile", "purgeWalletMobile", false, func(r *request) []string {
+		return []string{r.params["id"]}
+	}},
+	{"PUT", "/mobile-index-key", "setMobileIndexKey", false, func(r *request) []string {
+		return nil
+	}},
+	{"POST", "/mobile-hashes/purge", "purgeLegacyMobileHashes", false, func(r *request) []string {
+		return r.body.Keys
+	}},
+	{"POST", "/wallets/{id}/purchase", "purchaseCoins", false, func(r *request) []string {
+		return []string{r.params["id"], r.body.Amount.String(), r.body.Action, r.body.ActionEntityID, orDefault(r.body.Customer, chaincode.DefaultCustomer)}
+	}},
+	{"POST", "/wallets/{id}/spend", "spendCoins", false, func(r *request) []string {
+		return []string{r.params["id"], r.body.Amount.String(), r.body.Action, r.body.ActionEntityID, orDefault(r.body.Customer, chaincode.DefaultCustomer),
+			r.body.Nonce.String(), r.body.Expiry.String(), r.body.Signature}
+	}},
+	{"GET", "/wallets/{id}/nonce", "getWalletNonce", true, func(r *request) []string {
+		return []string{r.params["id"]}
+	}},
+	// executeSigned takes the operation and its signature by name, so the
+	// body is passed on
+	{"POST", "/signed-operations", "executeSigned", false, func(r *request) []string {
+		return []string{string(r.raw)}
+	}},
+	{"PUT", "/wallets/{id}/publicKey", "rotateWalletKey", false, func(r *request) []string {
+		return []string{r.params["id"], r.body.PublicKey, r.body.Nonce.String(), r.body.Expiry.String(), r.body.Signature}
+	}},
+	{"POST", "/wallets/{id}/recover", "recoverWallet", false, func(r *request) []string {
+		return []string{r.params["id"], r.body.SuccessorID, r.body.Reason, orDefault(r.body.Customer, chaincode.DefaultCustomer)}
+	}},
+	{"GET", "/wallets/{id}/transactions", "searchWalletTransactions", true, func(r *request) []string {
+		r.query.Set("walletId", r.params["id"])
+		return searchArgs(r, "walletId", "customer", "action")
+	}},
+	{"POST", "/treasure", "createTreasure", false, func(r *request) []string {
+		return []string{r.body.Balance.String(), r.body.ID}
+	}},
+	{"GET", "/treasure", "getTreasure", true, func(r *request) []string {
+		return nil
+	}},
+	{"POST", "/treasure/mint", "mintCoins", false, func(r *request) []string {
+		return []string{r.body.Amount.String(), r.body.Action, r.body.ActionEntityID}
+	}},
+	{"POST", "/treasure/burn", "burnCoins", false, func(r *request) []string {
+		return []string{r.body.Amount.String(), r.body.Action, r.body.ActionEntityID}
+	}},
+	{"GET", "/treasure/transactions", "searchTreasureTransactions", true, func(r *request) []string {
+		return searchArgs(r, "customer", "action")
+	}},
+	{"POST", "/treasury-ops", "proposeTreasuryOp", false, func(r *request) []string {
+		return []string{stringField(r.body.Operation), r.body.Amount.String(), r.body.Action, r.body.ActionEntityID, r.body.WalletID, orDefault(r.body.Customer, chaincode.DefaultCustomer)}
+	}},
+	{"GET", "/treasury-ops/{id}", "getTreasuryOp", true, func(r *request) []string {
+		return []string{r.params["id"]}
+	}},
+	{"POST", "/treasury-ops/{id}/approve", "approveTreasuryOp", false, func(r *request) []string {
+		return []string{r.params["id"]}
+	}},
+	{"POST", "/treasury-ops/{id}/execute", "executeTreasuryOp", false, func(r *request) []string {
+		return []string{r.params["id"]}
+	}},
+	{"GET", "/options/{customer}", "getOptions", true, func(r *request) []string {
+		return []string{r.params["customer"]}
+	}},
+	// setOptions only takes its settings by name, so the body is passed on
+	{"PUT", "/options/{customer}", "setOptions", false, func(r *request) []string {
+		return []string{withField(r.raw, "customer", r.params["customer"])}
+	}},
+	{"GET", "/endorsement-policies/{target}", "getEndorsementPolicy", true, func(r *request) []string {
+		return []string{r.params["target"]}
+	}},
+	{"GET", "/endorsement-policies/{target}/{id}", "getEndorsementPolicy", true, func(r *request) []string {
+		return []string{r.params["target"], r.params["id"]}
+	}},
+	{"PUT", "/endorsement-policies/{target}/{id}", "setEndorsementPolicy", false, func(r *request) []string {
+		return append([]string{r.params["target"], r.params["id"]}, r.body.Orgs...)
+	}},
+	{"GET", "/migrations/{docType}", "getMigrationPage", true, func(r *request) []string {
+		return []string{r.params["docType"], r.query.Get("bookmark"), r.query.Get("pageSize")}
+	}},
+	{"POST", "/migrations/{docType}", "migrate", false, func(r *request) []string {
+		return append([]string{r.params["docType"]}, r.body.Keys...)
+	}},
+	{"GET", "/pauses", "getPauses", true, func(r *request) []string {
+		return nil
+	}},
+	{"POST", "/pauses", "pauseContract", false, func(r *request) []string {
+		return []string{r.body.Customer, strings.Join(r.body.Functions, ","), r.body.Reason}
+	}},
+	{"DELETE", "/pauses", "unpauseContract", false, func(r *request) []string {
+		return nil
+	}},
+	{"DELETE", "/pauses/{customer}", "unpauseContract", false, func(r *request) []string {
+		return []string{r.params["customer"]}
+	}},
+	{"GET", "/describe", "describe", true, func(r *request) []string {
+		return nil
+	}},
+}
+
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// withField sets a field of a JSON object. Anything else is returned as is,
+// for the chaincode to reject.
+func withField(raw []byte, name, value string) string {
+	var fields map[string]interface{}
+	if json.Unmarshal(raw, &fields) != nil || fields == nil {
+		return string(raw)
+	}
+	fields[name] = value
+
+	asBytes, _ := json.Marshal(fields)
+	return string(asBytes)
+}
+
+// stringField returns a field that is a string for some routes and an object
+// for others, such as the operation of a treasury op and of executeSigned.
+func stringField(raw json.RawMessage) string {
+	var value string
+	if json.Unmarshal(raw, &value) != nil {
+		return string(raw)
+	}
+	return value
+}
+
+// withoutField removes a field of a JSON object. Anything else is returned as
+// is.
+func withoutField(raw []byte, name string) []byte {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil || fields == nil {
+		return raw
+	}
+	delete(fields, name)
+
+	asBytes, _ := json.Marshal(fields)
+	return asBytes
+}
+
+// searchArgs builds the filter, page and size arguments of a search from
+// the query string.
+func searchArgs(r *request, fields ...string) []string {
+	filter := map[string]string{}
+	for _, field := range fields {
+		if value := r.query.Get(field); value != "" {
+			filter[field] = value
+		}
+	}
+	filterBytes, _ := json.Marshal(filter)
+
+	return []string{string(filterBytes), orDefault(r.query.Get("page"), "1"), orDefault(r.query.Get("size"), "10")}
+}
+
+// match returns the parameters of path if it matches pattern.
+func match(pattern, path string) (map[string]string, bool) {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternParts) != len(pathParts) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			value, err := url.PathUnescape(pathParts[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			params[part[1:len(part)-1]] = value
+			continue
+		}
+		if part != pathParts[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// server hosts the chaincode on a devstub. Transactions run one at a time,
+// as they would be ordered on a channel.
+type server struct {
+	mu        sync.Mutex
+	stub      *devstub.Stub
+	statePath string
+	mspID     string
+	user      string
+	events    *eventBroker
+}
+
+// ServeHTTP dispatches to the route of the request. The identity can be
+// chosen per request with the X-MSP-ID and X-User headers.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" && r.URL.Path == "/events" {
+		s.events.serve(w, r)
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/metrics" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(chaincode.Metrics())
+		return
+	}
+
+	methodAllowed := false
+	for _, route := range routes {
+		params, found := match(route.pattern, r.URL.EscapedPath())
+		if !found {
+			continue
+		}
+		if route.method != r.Method {
+			methodAllowed = true
+			continue
+		}
+
+		req := &request{params: params, query: r.URL.Query()}
+		if r.Method != "GET" {
+			var raw json.RawMessage
+			err := json.NewDecoder(r.Body).Decode(&raw)
+			if err == nil {
+				err = json.Unmarshal(raw, &req.body)
+				req.raw = raw
+				if req.body.Transient != nil {
+					req.raw = withoutField(raw, "transient")
+				}
+			} else if err == io.EOF {
+				err = nil
+			}
+			if err != nil {
+				writeError(w, http.StatusBadRequest, chaincode.CodeInvalidArgument, "Invalid JSON body: "+err.Error())
+				return
+			}
+		}
+
+		s.execute(w, r, route, req)
+		return
+	}
+
+	if methodAllowed {
+		writeError(w, http.StatusMethodNotAllowed, chaincode.CodeInvalidArgument, "Method "+r.Method+" not allowed on "+r.URL.Path)
+		return
+	}
+	writeError(w, http.StatusNotFound, chaincode.CodeNotFound, "No route for "+r.URL.Path)
+}
+
+func (s *server) execute(w http.ResponseWriter, r *http.Request, route route, req *request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.stub.SetIdentity(orDefault(r.Header.Get("X-MSP-ID"), s.mspID), orDefault(r.Header.Get("X-User"), s.user))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, chaincode.CodeInternal, err.Error())
+		return
+	}
+
+	if len(req.body.Transient) > 0 {
+		transient := map[string][]byte{}
+		for key, value := range req.body.Transient {
+			transient[key] = []byte(value)
+		}
+		s.stub.SetTransient(transient)
+	}
+
+	args := route.args(req)
+	var response sc.Response
+	var events []*sc.ChaincodeEvent
+	switch {
+	case route.function == "init":
+		response, events = s.stub.Init(args)
+	case route.query:
+		response, events = s.stub.Query(append([]string{route.function}, args...))
+	default:
+		response, events = s.stub.Invoke(append([]string{route.function}, args...))
+	}
+
+	if response.GetStatus() >= shim.ERRORTHRESHOLD {
+		writeChaincodeError(w, response)
+		return
+	}
+
+	if !route.query {
+		if s.statePath != "" {
+			err = s.stub.Save(s.statePath)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, chaincode.CodeInternal, err.Error())
+				return
+			}
+		}
+
+		txID := s.stub.LastTxID()
+		data, _ := json.Marshal(map[string]string{"txId": txID, "function": route.function})
+		s.events.publish("transaction", txID, data)
+		for _, event := range events {
+			data = event.GetPayload()
+			if !json.Valid(data) {
+				data, _ = json.Marshal(string(data))
+			}
+			s.events.publish(event.GetEventName(), txID, data)
+		}
+	}
+
+	payload := response.GetPayload()
+	switch {
+	case len(payload) == 0 && r.Method == "GET":
+		writeError(w, http.StatusNotFound, chaincode.CodeNotFound, "Not found")
+	case len(payload) == 0:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(payload)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code chaincode.ErrorCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "code": code, "message": message})
+}
+
+// writeChaincodeError answers with the status and the error code of a failed
+// chaincode response.
+func writeChaincodeError(w http.ResponseWriter, response sc.Response) {
+	var chaincodeErr chaincode.Error
+	if json.Unmarshal(response.GetPayload(), &chaincodeErr) != nil || chaincodeErr.Code == "" {
+		chaincodeErr.Code, _ = chaincode.ErrorCodeOf(response.GetStatus())
+	}
+	writeError(w, int(response.GetStatus()), chaincodeErr.Code, response.GetMessage())
+}
+
+// eventBroker fans out committed transactions and chaincode events to the
+// clients of the /events stream. The "transaction" event is made up by the
+// devserver; the others are the chaincode events of the transaction.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan string]bool
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: map[chan string]bool{}}
+}
+
+// publish sends an event with JSON data to every client.
+func (b *eventBroker) publish(name, txID string, data []byte) {
+	message := fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", txID, name, data)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for subscriber := range b.subscribers {
+		// A client that does not keep up misses events rather than
+		// blocking transactions
+		select {
+		case subscriber <- message:
+		default:
+		}
+	}
+}
+
+// serve streams events to one client as Server-Sent Events until it
+// disconnects.
+func (b *eventBroker) serve(w http.ResponseWriter, r *http.Request) {
+	flusher, canFlush := w.(http.Flusher)
+	if !canFlush {
+		writeError(w, http.StatusInternalServerError, chaincode.CodeInternal, "Streaming is not supported")
+		return
+	}
+
+	subscriber := make(chan string, 64)
+	b.mu.Lock()
+	b.subscribers[subscriber] = true
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.subscribers, subscriber)
+		b.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case message := <-subscriber:
+			fmt.Fprint(w, message)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
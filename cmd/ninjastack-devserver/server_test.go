@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+	"github.com/ninjastack101/hyperladger-chaincode/devstub"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	stub := devstub.New("ninjastack", new(chaincode.SmartContract))
+	s := &server{stub: stub, mspID: "Org1MSP", user: "admin", events: newEventBroker()}
+	ts := httptest.NewServer(s)
+
+	status, _ := call(t, ts, "POST", "/init", `{"treasury":1000}`)
+	if status != http.StatusNoContent {
+		t.Fatalf("init returned %d", status)
+	}
+	return ts
+}
+
+func call(t *testing.T, ts *httptest.Server, method, path, body string) (int, map[string]interface{}) {
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]interface{}
+	json.Unmarshal(data, &result)
+	return resp.StatusCode, result
+}
+
+func TestWalletRoutes(t *testing.T) {
+	t.Log("Test creating, reading and spending from a wallet over REST")
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	status, wallet := call(t, ts, "POST", "/wallets", `{"id":"w1","mobileHash":"hash1","amount":100,"action":"registration","actionEntityId":"w1"}`)
+	if status != http.StatusOK || wallet["amount"] != float64(100) {
+		t.Fatalf("createWallet returned %d %v", status, wallet)
+	}
+
+	status, _ = call(t, ts, "POST", "/wallets/w1/spend", `{"amount":40,"action":"order","actionEntityId":"1"}`)
+	if status != http.StatusNoContent {
+		t.Fatalf("spendCoins returned %d", status)
+	}
+
+	status, wallet = call(t, ts, "GET", "/wallets/w1", "")
+	if status != http.StatusOK || wallet["amount"] != float64(60) {
+		t.Fatalf("getWallet returned %d %v", status, wallet)
+	}
+
+	status, wallet = call(t, ts, "GET", "/mobile-hashes/hash1/wallet", "")
+	if status != http.StatusOK || wallet["id"] != "w1" {
+		t.Fatalf("getWalletByMobileHash returned %d %v", status, wallet)
+	}
+
+	status, treasure := call(t, ts, "GET", "/treasure", "")
+	if status != http.StatusOK || treasure["balance"] != float64(940) {
+		t.Fatalf("getTreasure returned %d %v", status, treasure)
+	}
+
+	status, metrics := call(t, ts, "GET", "/metrics", "")
+	spend, _ := metrics["spendCoins"].(map[string]interface{})
+	if status != http.StatusOK || spend["calls"] == nil || spend["calls"].(float64) < 1 {
+		t.Fatalf("metrics returned %d %v", status, metrics)
+	}
+
+	status, _ = call(t, ts, "POST", "/pauses", `{"functions":["spendCoins"],"reason":"incident"}`)
+	if status != http.StatusOK {
+		t.Fatalf("pauseContract returned %d", status)
+	}
+
+	status, result := call(t, ts, "POST", "/wallets/w1/spend", `{"amount":1,"action":"order","actionEntityId":"paused"}`)
+	if status != http.StatusServiceUnavailable || result["code"] != "PAUSED" {
+		t.Fatalf("spending while paused returned %d %v", status, result)
+	}
+
+	status, _ = call(t, ts, "DELETE", "/pauses", "")
+	if status != http.StatusNoContent {
+		t.Fatalf("unpauseContract returned %d", status)
+	}
+
+	status, description := call(t, ts, "GET", "/describe", "")
+	if status != http.StatusOK || description["functions"] == nil {
+		t.Fatalf("describe returned %d %v", status, description)
+	}
+
+	status, options := call(t, ts, "PUT", "/options/partner", `{"registration":20,"maxSupply":5000}`)
+	if status != http.StatusOK || options["customer"] != "partner" || options["maxSupply"] != float64(5000) {
+		t.Fatalf("setOptions returned %d %v", status, options)
+	}
+
+	status, op := call(t, ts, "POST", "/treasury-ops", `{"operation":"mint","amount":10,"action":"campaign","actionEntityId":"c1"}`)
+	if status != http.StatusOK || op["operation"] != "mint" {
+		t.Fatalf("proposeTreasuryOp returned %d %v", status, op)
+	}
+
+	// ---- Negative Cases ----
+	status, result = call(t, ts, "POST", "/wallets/w1/spend", `{"amount":10,"action":"order","actionEntityId":"1"}`)
+	if status != http.StatusConflict || result["code"] != "DOUBLE_HIT" || result["message"] == "" {
+		t.Fatalf("double hit returned %d %v", status, result)
+	}
+
+	status, result = call(t, ts, "POST", "/wallets/w1/spend", `{"amount":1000,"action":"order","actionEntityId":"2"}`)
+	if status != http.StatusPaymentRequired || result["code"] != "INSUFFICIENT_FUNDS" {
+		t.Fatalf("overspending returned %d %v", status, result)
+	}
+
+	status, wallet = call(t, ts, "PUT", "/wallets/w1/publicKey", `{"publicKey":"MCowBQYDK2VwAyEAI7xUkSwebpLEqGglyGfif/3FVb/71CRPF6Jqv//ull0="}`)
+	if status != http.StatusOK || wallet["publicKey"] == nil {
+		t.Fatalf("rotateWalletKey returned %d %v", status, wallet)
+	}
+
+	status, walletNonce := call(t, ts, "GET", "/wallets/w1/nonce", "")
+	if status != http.StatusOK || walletNonce["nonce"] != float64(0) {
+		t.Fatalf("getWalletNonce returned %d %v", status, walletNonce)
+	}
+
+	status, result = call(t, ts, "POST", "/wallets/w1/spend", `{"amount":1,"action":"order","actionEntityId":"unsigned"}`)
+	if status != http.StatusForbidden || result["code"] != "FORBIDDEN" {
+		t.Fatalf("unsigned spend returned %d %v", status, result)
+	}
+
+	status, wallet = call(t, ts, "POST", "/wallets/w1/recover", `{"successorId":"w2","reason":"LOST_ACCESS"}`)
+	if status != http.StatusOK || wallet["closed"] != true || wallet["successorId"] != "w2" {
+		t.Fatalf("recoverWallet returned %d %v", status, wallet)
+	}
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/wallets/unknown", "", http.StatusNotFound},
+		{"GET", "/unknown", "", http.StatusNotFound},
+		{"DELETE", "/wallets/w1", "", http.StatusMethodNotAllowed},
+		{"POST", "/wallets", "{", http.StatusBadRequest},
+		{"PUT", "/options/partner", `{"registration":20,"stateDb":"leveldb"}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		status, _ = call(t, ts, test.method, test.path, test.body)
+		if status != test.status {
+			t.Fatalf("%s %s returned %d, expected %d", test.method, test.path, status, test.status)
+		}
+	}
+}
+
+func TestEveryFunctionHasRoute(t *testing.T) {
+	t.Log("Test that every function of the chaincode is served")
+	served := map[string]bool{}
+	for _, route := range routes {
+		served[route.function] = true
+	}
+
+	for _, function := range chaincode.Functions() {
+		if !served[function.Name] {
+			t.Errorf("function %s has no route", function.Name)
+		}
+	}
+}
+
+func TestMobileRoutes(t *testing.T) {
+	t.Log("Test passing mobiles and the mobile index key in the transient field")
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	status, _ := call(t, ts, "PUT", "/mobile-index-key", `{"transient":{"indexKey":"0123456789abcdef0123456789abcdef"}}`)
+	if status != http.StatusNoContent {
+		t.Fatalf("setMobileIndexKey returned %d", status)
+	}
+
+	status, wallet := call(t, ts, "POST", "/wallets", `{"id":"w1","amount":100,"action":"registration","actionEntityId":"w1","transient":{"mobile":"+4915112345678","salt":"0123456789abcdef"}}`)
+	if status != http.StatusOK || wallet["mobileHash"] == "" || wallet["mobileHash"] == nil {
+		t.Fatalf("createWallet returned %d %v", status, wallet)
+	}
+
+	status, wallet = call(t, ts, "POST", "/wallets/lookup", `{"transient":{"mobile":"+4915112345678"}}`)
+	if status != http.StatusOK || wallet["id"] != "w1" {
+		t.Fatalf("getWalletByMobileHash returned %d %v", status, wallet)
+	}
+
+	status, progress := call(t, ts, "POST", "/mobile-hashes/purge", `{"keys":[]}`)
+	if status != http.StatusOK || progress["migrated"] != float64(0) {
+		t.Fatalf("purgeLegacyMobileHashes returned %d %v", status, progress)
+	}
+
+	status, wallet = call(t, ts, "DELETE", "/wallets/w1/mobile", "")
+	if status != http.StatusOK || wallet["id"] != "w1" {
+		t.Fatalf("purgeWalletMobile returned %d %v", status, wallet)
+	}
+
+	// ---- Negative Cases ----
+	status, result := call(t, ts, "POST", "/wallets/lookup", `{"transient":{"mobile":"+4915112345678"}}`)
+	if status != http.StatusNotFound {
+		t.Fatalf("looking up a purged mobile returned %d %v", status, result)
+	}
+
+	status, result = call(t, ts, "POST", "/wallets", `{"id":"w2","mobileHash":"hash2","action":"registration","actionEntityId":"w2"}`)
+	if status != http.StatusBadRequest || result["code"] != "INVALID_ARGUMENT" {
+		t.Fatalf("createWallet with a mobileHash returned %d %v", status, result)
+	}
+
+	status, result = call(t, ts, "POST", "/signed-operations", `{"operation":{"function":"spendCoins","args":["w1","1","order","relayed"]},"transient":{"mobile":"ignored"}}`)
+	if status != http.StatusForbidden || result["code"] != "FORBIDDEN" {
+		t.Fatalf("unsigned executeSigned returned %d %v", status, result)
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	t.Log("Test committed transactions are streamed as Server-Sent Events")
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %s", resp.Header.Get("Content-Type"))
+	}
+
+	call(t, ts, "GET", "/treasure", "")
+	call(t, ts, "POST", "/wallets", `{"id":"w1","mobileHash":"hash1","amount":10}`)
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 7 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+
+	// Queries are not streamed, so the first event is createWallet
+	if lines[1] != "event: transaction" || !strings.Contains(lines[2], `"function":"createWallet"`) {
+		t.Fatalf("unexpected event %q", lines)
+	}
+
+	// followed by the chaincode event of the transaction
+	if lines[5] != "event: createWallet" || !strings.Contains(lines[6], `"args":["w1"`) {
+		t.Fatalf("unexpected chaincode event %q", lines)
+	}
+}
@@ -0,0 +1,79 @@
+// Command ninjastack-openapi writes an OpenAPI 3 document for the chaincode.
+//
+//	ninjastack-openapi [-in description.json] [-server url] [-out openapi.json]
+//
+// The document is generated from the output of the describe query, so it can
+// be generated for a deployed chaincode with
+//
+//	peer chaincode query -C mychannel -n ninjastack -c '{"Args":["describe"]}' > description.json
+//
+// Without -in the description of this build is used. Every function becomes a
+// POST /{function} operation whose request body is the JSON object form of
+// its arguments, which a gateway can pass to the chaincode as is.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+)
+
+func main() {
+	in := flag.String("in", "", "describe output of a deployed chaincode, or - for stdin; defaults to this build")
+	server := flag.String("server", "", "URL of the gateway serving the API")
+	out := flag.String("out", "", "file to write the document to; defaults to stdout")
+	flag.Parse()
+
+	err := run(*in, *server, *out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ninjastack-openapi:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, server, out string) error {
+	description, err := readDescription(in)
+	if err != nil {
+		return err
+	}
+
+	document, err := json.MarshalIndent(generate(description, server), "", "  ")
+	if err != nil {
+		return err
+	}
+	document = append(document, '\n')
+
+	if out == "" {
+		_, err = os.Stdout.Write(document)
+		return err
+	}
+	return ioutil.WriteFile(out, document, 0644)
+}
+
+func readDescription(path string) (*chaincode.Description, error) {
+	if path == "" {
+		return chaincode.Describe(), nil
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var description = new(chaincode.Description)
+	err = json.Unmarshal(data, description)
+	if err != nil {
+		return nil, fmt.Errorf("invalid description: %v", err)
+	}
+	return description, nil
+}
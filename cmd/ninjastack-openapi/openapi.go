@@ -0,0 +1,173 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+)
+
+// object is a JSON object of the OpenAPI document.
+type object = map[string]interface{}
+
+// generate returns the OpenAPI document of a chaincode description.
+func generate(description *chaincode.Description, server string) object {
+	schemas := object{"Error": errorSchema(description.Errors)}
+	for _, document := range description.Documents {
+		schemas[schemaName(document.Name)] = documentSchema(document.Fields)
+	}
+
+	paths := object{}
+	for _, fn := range description.Functions {
+		paths["/"+fn.Name] = object{"post": operation(fn, description.Errors)}
+	}
+
+	document := object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "ninjastack chaincode",
+			"version": description.Version,
+		},
+		"paths":      paths,
+		"components": object{"schemas": schemas},
+	}
+	if server != "" {
+		document["servers"] = []object{{"url": server}}
+	}
+	return document
+}
+
+func operation(fn chaincode.Function, errors []chaincode.ErrorDescription) object {
+	success := object{"description": "The transaction succeeded"}
+	if fn.Returns != "" {
+		var schema object = ref(strings.TrimPrefix(fn.Returns, "[]"))
+		if strings.HasPrefix(fn.Returns, "[]") {
+			schema = object{"type": "array", "items": schema}
+		}
+		success["content"] = object{"application/json": object{"schema": schema}}
+	}
+
+	responses := object{"200": success}
+	for _, e := range errors {
+		status := strconv.Itoa(int(e.Status))
+		if _, found := responses[status]; found {
+			continue
+		}
+		responses[status] = object{
+			"description": string(e.Code),
+			"content":     object{"application/json": object{"schema": ref("Error")}},
+		}
+	}
+
+	op := object{
+		"operationId": fn.Name,
+		"responses":   responses,
+		// Read-only functions are evaluated on one peer instead of submitted
+		"x-read-only": fn.ReadOnly,
+	}
+	if fn.Role != chaincode.RoleAny {
+		op["x-role"] = fn.Role
+	}
+	if len(fn.Args) > 0 {
+		op["requestBody"] = object{
+			"required": hasRequired(fn.Args),
+			"content":  object{"application/json": object{"schema": argsSchema(fn.Args)}},
+		}
+	}
+	return op
+}
+
+// argsSchema is the schema of the JSON object form of the arguments.
+func argsSchema(args []chaincode.ArgField) object {
+	properties := object{}
+	var required []string
+	for _, arg := range args {
+		property := argSchema(arg)
+		if arg.Default != "" {
+			property["default"] = arg.Default
+		}
+		if arg.Sensitive {
+			property["format"] = "password"
+		}
+		if arg.Deprecated {
+			property["deprecated"] = true
+		}
+		properties[arg.Name] = property
+		if arg.Required {
+			required = append(required, arg.Name)
+		}
+	}
+
+	schema := object{"type": "object", "properties": properties, "additionalProperties": false}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func argSchema(arg chaincode.ArgField) object {
+	switch arg.Type {
+	case chaincode.ArgList, chaincode.ArgVariadic:
+		return object{"type": "array", "items": object{"type": "string"}}
+	case chaincode.ArgNumber, chaincode.ArgInteger, chaincode.ArgObject:
+		return object{"type": arg.Type}
+	}
+	return object{"type": "string"}
+}
+
+func hasRequired(args []chaincode.ArgField) bool {
+	for _, arg := range args {
+		if arg.Required {
+			return true
+		}
+	}
+	return false
+}
+
+func documentSchema(fields []chaincode.DocumentField) object {
+	properties := object{}
+	for _, field := range fields {
+		properties[field.Name] = fieldSchema(field.Type, field.Items, field.Fields)
+	}
+	return object{"type": "object", "properties": properties}
+}
+
+func fieldSchema(fieldType, items string, fields []chaincode.DocumentField) object {
+	switch fieldType {
+	case "array":
+		return object{"type": "array", "items": fieldSchema(items, "", fields)}
+	case "object":
+		if len(fields) > 0 {
+			return documentSchema(fields)
+		}
+	}
+	return object{"type": fieldType}
+}
+
+func errorSchema(errors []chaincode.ErrorDescription) object {
+	var codes []string
+	for _, e := range errors {
+		codes = append(codes, string(e.Code))
+	}
+	return object{
+		"type":     "object",
+		"required": []string{"code", "message"},
+		"properties": object{
+			"code":    object{"type": "string", "enum": codes},
+			"message": object{"type": "string"},
+		},
+	}
+}
+
+func ref(name string) object {
+	return object{"$ref": "#/components/schemas/" + schemaName(name)}
+}
+
+// schemaName turns a document name such as walletTransaction into the
+// component name WalletTransaction.
+func schemaName(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
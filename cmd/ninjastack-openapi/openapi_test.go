@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Log("Test the OpenAPI document covers every function")
+	description := chaincode.Describe()
+
+	// Round trip through JSON to check the document as a client reads it
+	data, err := json.Marshal(generate(description, "https://gateway.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := document["paths"].(map[string]interface{})
+	if len(paths) != len(description.Functions) {
+		t.Fatalf("expected %d paths, got %d", len(description.Functions), len(paths))
+	}
+
+	schemas := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, ref := range findRefs(document) {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if _, found := schemas[name]; !found {
+			t.Fatalf("unresolved reference %s", ref)
+		}
+	}
+
+	create := paths["/createWallet"].(map[string]interface{})["post"].(map[string]interface{})
+	schema := create["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+	if !reflect.DeepEqual([]interface{}{"walletId"}, schema["required"]) {
+		t.Fatalf("unexpected required arguments %v", schema["required"])
+	}
+	responses := create["responses"].(map[string]interface{})
+	if _, found := responses["409"]; !found {
+		t.Fatalf("missing the double hit response in %v", responses)
+	}
+
+	search := paths["/searchWallets"].(map[string]interface{})["post"].(map[string]interface{})
+	if search["x-read-only"] != true {
+		t.Fatalf("searchWallets is not read-only")
+	}
+	mint := paths["/mintCoins"].(map[string]interface{})["post"].(map[string]interface{})
+	if mint["x-role"] != chaincode.RoleAdmin {
+		t.Fatalf("mintCoins does not require the admin role")
+	}
+}
+
+func TestRunWithDescriptionFile(t *testing.T) {
+	t.Log("Test generating the document of a deployed chaincode")
+	dir := t.TempDir()
+
+	description := chaincode.Describe()
+	description.Version = "1.2.0"
+	data, _ := json.Marshal(description)
+	in := filepath.Join(dir, "description.json")
+	if err := ioutil.WriteFile(in, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "openapi.json")
+	if err := run(in, "", out); err != nil {
+		t.Fatal(err)
+	}
+
+	var document struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	data, _ = ioutil.ReadFile(out)
+	if err := json.Unmarshal(data, &document); err != nil || document.Info.Version != "1.2.0" {
+		t.Fatalf("unexpected document %s: %v", data, err)
+	}
+
+	// ---- Negative Cases ----
+	ioutil.WriteFile(in, []byte("not json"), 0644)
+	if err := run(in, "", out); err == nil {
+		t.Fatal("expected an error for an invalid description")
+	}
+}
+
+func findRefs(value interface{}) []string {
+	var refs []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if ref, isString := child.(string); key == "$ref" && isString {
+				refs = append(refs, ref)
+				continue
+			}
+			refs = append(refs, findRefs(child)...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			refs = append(refs, findRefs(child)...)
+		}
+	}
+	return refs
+}
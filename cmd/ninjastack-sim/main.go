@@ -0,0 +1,258 @@
+// Command ninjastack-sim runs the chaincode locally on a ledger that is kept
+// in a JSON file, without a Fabric network.
+//
+//	ninjastack-sim [-state file] [-msp id] [-user name] init [args...]
+//	ninjastack-sim [-state file] [-msp id] [-user name] invoke <function> [args...]
+//	ninjastack-sim [-state file] [-msp id] [-user name] query <function> [args...]
+//	ninjastack-sim [-state file] [-msp id] [-user name] replay <script|->
+//	ninjastack-sim [-state file] dump
+//
+// invoke commits the writes of a successful transaction to the state file,
+// query never does. A replay script holds one init, invoke or query command
+// per line, plus "as <msp> <user>" to switch the identity. Blank lines and
+// lines starting with # are ignored, and arguments can be quoted with single
+// or double quotes.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
+	"github.com/ninjastack101/hyperladger-chaincode/devstub"
+)
+
+func main() {
+	statePath := flag.String("state", "ledger.json", "file the ledger is kept in")
+	mspID := flag.String("msp", "Org1MSP", "MSP of the invoking identity")
+	user := flag.String("user", "admin", "common name of the invoking identity")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ninjastack-sim [flags] init|invoke|query|replay|dump [args...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	chaincode.SetLogLevel(shim.LogError)
+
+	failed, err := run(os.Stdout, *statePath, *mspID, *user, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ninjastack-sim:", err)
+		os.Exit(2)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// run executes one command against the ledger at statePath and returns the
+// number of failed transactions.
+func run(out io.Writer, statePath, mspID, user string, args []string) (int, error) {
+	stub := devstub.New("ninjastack", new(chaincode.SmartContract))
+	err := stub.Load(statePath)
+	if err != nil {
+		return 0, err
+	}
+
+	err = stub.SetIdentity(mspID, user)
+	if err != nil {
+		return 0, err
+	}
+
+	var failed int
+	switch args[0] {
+	case "dump":
+		return 0, dump(out, stub)
+	case "replay":
+		if len(args) < 2 {
+			return 0, errors.New("replay expects a script file, or - for stdin")
+		}
+		failed, err = replay(out, stub, args[1])
+	default:
+		failed, err = execute(out, stub, args)
+	}
+	if err != nil {
+		return failed, err
+	}
+
+	return failed, stub.Save(statePath)
+}
+
+// execute runs an init, invoke or query command and prints its response.
+func execute(out io.Writer, stub *devstub.Stub, args []string) (int, error) {
+	var response sc.Response
+	var events []*sc.ChaincodeEvent
+	switch args[0] {
+	case "init":
+		response, events = stub.Init(args[1:])
+	case "invoke", "query":
+		if len(args) < 2 {
+			return 0, errors.New(args[0] + " expects a function name")
+		}
+		if args[0] == "invoke" {
+			response, events = stub.Invoke(args[1:])
+		} else {
+			response, events = stub.Query(args[1:])
+		}
+	default:
+		return 0, errors.New("unknown command " + args[0])
+	}
+
+	printResponse(out, response, events)
+	if response.GetStatus() >= shim.ERRORTHRESHOLD {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// replay runs the commands of a script in order. Failed transactions are
+// reported and counted; an invalid line stops the replay.
+func replay(out io.Writer, stub *devstub.Stub, path string) (int, error) {
+	var script io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		script = file
+	}
+
+	var failed int
+	scanner := bufio.NewScanner(script)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			return failed, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+
+		fmt.Fprintf(out, "> %s\n", line)
+		if args[0] == "as" {
+			if len(args) != 3 {
+				return failed, fmt.Errorf("line %d: as expects an MSP and a user", lineNumber)
+			}
+			err = stub.SetIdentity(args[1], args[2])
+			if err != nil {
+				return failed, err
+			}
+			continue
+		}
+
+		n, err := execute(out, stub, args)
+		if err != nil {
+			return failed, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		failed += n
+	}
+
+	return failed, scanner.Err()
+}
+
+func printResponse(out io.Writer, response sc.Response, events []*sc.ChaincodeEvent) {
+	fmt.Fprintf(out, "status: %d\n", response.GetStatus())
+	if response.GetMessage() != "" {
+		fmt.Fprintf(out, "message: %s\n", response.GetMessage())
+	}
+	if len(response.GetPayload()) != 0 {
+		fmt.Fprintf(out, "payload: %s\n", formatJSON(response.GetPayload()))
+	}
+	for _, event := range events {
+		fmt.Fprintf(out, "event: %s %s\n", event.GetEventName(), formatJSON(event.GetPayload()))
+	}
+}
+
+// dump prints every key of the ledger with its value.
+func dump(out io.Writer, stub *devstub.Stub) error {
+	keys := make([]string, 0, len(stub.State))
+	for key := range stub.State {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(out, "%s %s\n", strconv.QuoteToASCII(key), formatJSON(stub.State[key]))
+	}
+	return nil
+}
+
+func formatJSON(data []byte) string {
+	var buffer bytes.Buffer
+	if json.Indent(&buffer, data, "", "  ") != nil {
+		return string(data)
+	}
+	return buffer.String()
+}
+
+// splitArgs splits a script line into arguments. Single quotes keep their
+// content as is, double quotes allow \" and \\ escapes.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				i++
+				current.WriteRune(runes[i])
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
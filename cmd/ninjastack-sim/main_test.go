@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	t.Log("Test splitting script lines into arguments")
+	tests := []struct {
+		line string
+		args []string
+	}{
+		{"invoke getWallet w1", []string{"invoke", "getWallet", "w1"}},
+		{"  invoke   getWallet\tw1  ", []string{"invoke", "getWallet", "w1"}},
+		{`query searchWalletTransactions '{"walletId":"w1"}'`, []string{"query", "searchWalletTransactions", `{"walletId":"w1"}`}},
+		{`invoke createWallet w1 "a \"b\" \\ c" ''`, []string{"invoke", "createWallet", "w1", `a "b" \ c`, ""}},
+	}
+
+	for _, test := range tests {
+		args, err := splitArgs(test.line)
+		if err != nil || !reflect.DeepEqual(test.args, args) {
+			t.Fatalf("splitArgs(%q) = %q, %v", test.line, args, err)
+		}
+	}
+
+	// ---- Negative Cases ----
+	for _, line := range []string{`invoke "getWallet`, "   "} {
+		_, err := splitArgs(line)
+		if err == nil {
+			t.Fatalf("expected an error for %q", line)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Log("Test replaying a script and continuing on the saved ledger")
+	dir, err := ioutil.TempDir("", "ninjastack-sim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	statePath := filepath.Join(dir, "ledger.json")
+	scriptPath := filepath.Join(dir, "script.txt")
+	script := `# create a wallet and buy coins
+init '{"treasury":1000}'
+invoke createWallet w1 hash1 100 registration w1
+invoke purchaseCoins w1 50 order 1
+invoke purchaseCoins w1 50 order 1
+as Org2MSP user2
+query getWallet w1
+`
+	err = ioutil.WriteFile(scriptPath, []byte(script), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	failed, err := run(&out, statePath, "Org1MSP", "admin", []string{"replay", scriptPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second purchase is a double hit
+	if failed != 1 || !strings.Contains(out.String(), "status: 409") || !strings.Contains(out.String(), `"amount": 150`) ||
+		!strings.Contains(out.String(), "event: purchaseCoins") {
+		t.Fatalf("unexpected replay output (%d failed):\n%s", failed, out.String())
+	}
+
+	out.Reset()
+	failed, err = run(&out, statePath, "Org1MSP", "admin", []string{"query", "getTreasure"})
+	if err != nil || failed != 0 || !strings.Contains(out.String(), `"balance": 850`) {
+		t.Fatalf("unexpected query output (%v):\n%s", err, out.String())
+	}
+
+	// ---- Negative Cases ----
+	_, err = run(&out, statePath, "Org1MSP", "admin", []string{"transfer"})
+	if err == nil {
+		t.Fatal("expected an error for an unknown command")
+	}
+}
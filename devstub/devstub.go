@@ -0,0 +1,364 @@
+// Package devstub runs a chaincode outside of a Fabric network. It wraps a
+// MockStub with a client identity, discards the writes of failed
+// transactions like a peer would and persists the ledger in a JSON snapshot.
+package devstub
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	"github.com/hyperledger/fabric/protos/msp"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Stub hosts a chaincode on a MockStub. Transactions run on behalf of the
+// identity set with SetIdentity.
+type Stub struct {
+	*shim.MockStub
+	cc        shim.Chaincode
+	args      [][]byte
+	creator   []byte
+	transient map[string][]byte
+	txCount   int
+}
+
+// snapshot is the on-disk format of a ledger. Values are stored as strings
+// so that the JSON documents of the chaincode stay readable.
+type snapshot struct {
+	TxCount             int                          `json:"txCount"`
+	State               map[string]string            `json:"state"`
+	PrivateData         map[string]map[string]string `json:"privateData,omitempty"`
+	EndorsementPolicies map[string]map[string][]byte `json:"endorsementPolicies,omitempty"`
+}
+
+func New(name string, cc shim.Chaincode) *Stub {
+	return &Stub{MockStub: shim.NewMockStub(name, cc), cc: cc}
+}
+
+// NewIdentity returns a serialized identity with a self-signed certificate
+// for name in mspID, as returned by GetCreator on a peer.
+func NewIdentity(mspID, name string) ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name, Organization: []string{mspID}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+}
+
+// SetIdentity makes the following transactions run as name in mspID.
+func (s *Stub) SetIdentity(mspID, name string) error {
+	creator, err := NewIdentity(mspID, name)
+	if err != nil {
+		return err
+	}
+	s.creator = creator
+	return nil
+}
+
+func (s *Stub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+func (s *Stub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *Stub) GetStringArgs() []string {
+	args := make([]string, 0, len(s.args))
+	for _, arg := range s.args {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+func (s *Stub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+// SetTransient sets the transient map of the next transaction, such as the
+// mobile identifiers of the chaincode, which are never passed as arguments.
+func (s *Stub) SetTransient(transient map[string][]byte) {
+	s.transient = transient
+}
+
+// GetTransient returns the transient map of the running transaction, which
+// the MockStub does not carry.
+func (s *Stub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+// DelPrivateData deletes from the private data of the MockStub, which does
+// not implement it.
+func (s *Stub) DelPrivateData(collection, key string) error {
+	delete(s.PvtState[collection], key)
+	return nil
+}
+
+// Init runs Init of the chaincode with args.
+func (s *Stub) Init(args []string) (sc.Response, []*sc.ChaincodeEvent) {
+	return s.run(append([]string{"init"}, args...), true, true)
+}
+
+// Invoke runs a function, args[0], and commits its writes if it succeeds.
+func (s *Stub) Invoke(args []string) (sc.Response, []*sc.ChaincodeEvent) {
+	return s.run(args, false, true)
+}
+
+// Query runs a function like Invoke but never commits its writes, like a
+// proposal that is not sent to the orderer.
+func (s *Stub) Query(args []string) (sc.Response, []*sc.ChaincodeEvent) {
+	return s.run(args, false, false)
+}
+
+func (s *Stub) run(args []string, init, commit bool) (sc.Response, []*sc.ChaincodeEvent) {
+	s.args = nil
+	for _, arg := range args {
+		s.args = append(s.args, []byte(arg))
+	}
+
+	before := s.snapshot()
+
+	s.txCount++
+	txID := "tx" + strconv.Itoa(s.txCount)
+	s.MockTransactionStart(txID)
+	var response sc.Response
+	if init {
+		response = s.cc.Init(s)
+	} else {
+		response = s.cc.Invoke(s)
+	}
+	s.transient = nil
+	s.MockTransactionEnd(txID)
+
+	events := s.drainEvents(txID)
+	if !commit || response.GetStatus() >= shim.ERRORTHRESHOLD {
+		s.restore(before)
+		s.txCount = before.TxCount + 1
+	}
+	if response.GetStatus() >= shim.ERRORTHRESHOLD {
+		events = nil
+	}
+
+	return response, events
+}
+
+// LastTxID returns the id of the last transaction.
+func (s *Stub) LastTxID() string {
+	return "tx" + strconv.Itoa(s.txCount)
+}
+
+func (s *Stub) drainEvents(txID string) []*sc.ChaincodeEvent {
+	var events []*sc.ChaincodeEvent
+	for {
+		select {
+		case event := <-s.ChaincodeEventsChannel:
+			event.TxId = txID
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func (s *Stub) snapshot() *snapshot {
+	snapshot := &snapshot{
+		TxCount:             s.txCount,
+		State:               map[string]string{},
+		PrivateData:         map[string]map[string]string{},
+		EndorsementPolicies: copyPolicies(s.EndorsementPolicies),
+	}
+	for key, value := range s.State {
+		snapshot.State[key] = string(value)
+	}
+	for collection, state := range s.PvtState {
+		snapshot.PrivateData[collection] = map[string]string{}
+		for key, value := range state {
+			snapshot.PrivateData[collection][key] = string(value)
+		}
+	}
+	return snapshot
+}
+
+// copyPolicies copies endorsement policies, which the MockStub keeps by
+// collection and key. The policies of public state have no collection.
+func copyPolicies(policies map[string]map[string][]byte) map[string]map[string][]byte {
+	copied := map[string]map[string][]byte{}
+	for collection, keys := range policies {
+		copied[collection] = map[string][]byte{}
+		for key, policy := range keys {
+			copied[collection][key] = policy
+		}
+	}
+	return copied
+}
+
+func (s *Stub) restore(snapshot *snapshot) {
+	s.txCount = snapshot.TxCount
+
+	s.State = map[string][]byte{}
+	keys := make([]string, 0, len(snapshot.State))
+	for key, value := range snapshot.State {
+		s.State[key] = []byte(value)
+		keys = append(keys, key)
+	}
+
+	// The MockStub keeps its keys sorted for range queries
+	sort.Strings(keys)
+	s.Keys.Init()
+	for _, key := range keys {
+		s.Keys.PushBack(key)
+	}
+
+	s.PvtState = map[string]map[string][]byte{}
+	for collection, state := range snapshot.PrivateData {
+		s.PvtState[collection] = map[string][]byte{}
+		for key, value := range state {
+			s.PvtState[collection][key] = []byte(value)
+		}
+	}
+
+	s.EndorsementPolicies = copyPolicies(snapshot.EndorsementPolicies)
+}
+
+// GetQueryResult fails like a peer with a LevelDB state database, which the
+// ledger of the Stub resembles, so that chaincodes fall back to composite key
+// queries. The MockStub does not implement rich queries either.
+func (s *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, errors.New("ExecuteQuery not supported for leveldb")
+}
+
+// GetStateByPartialCompositeKeyWithPagination pages over the keys of a
+// partial composite key like LevelDB does, which the MockStub does not: the
+// bookmark is the key the next page starts at, and is empty after the last
+// page.
+func (s *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *sc.QueryResponseMetadata, error) {
+	iterator, err := s.MockStub.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iterator.Close()
+
+	var page = new(pageIterator)
+	var metadata = new(sc.QueryResponseMetadata)
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if kv.Key < bookmark {
+			continue
+		}
+
+		if int32(len(page.results)) == pageSize {
+			metadata.Bookmark = kv.Key
+			break
+		}
+		page.results = append(page.results, kv)
+	}
+
+	metadata.FetchedRecordsCount = int32(len(page.results))
+	return page, metadata, nil
+}
+
+// pageIterator iterates over one page of results.
+type pageIterator struct {
+	results []*queryresult.KV
+}
+
+func (iter *pageIterator) HasNext() bool {
+	return len(iter.results) > 0
+}
+
+func (iter *pageIterator) Next() (*queryresult.KV, error) {
+	kv := iter.results[0]
+	iter.results = iter.results[1:]
+	return kv, nil
+}
+
+func (iter *pageIterator) Close() error {
+	iter.results = nil
+	return nil
+}
+
+// Load replaces the ledger with the snapshot at path. A missing file is an
+// empty ledger.
+func (s *Stub) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		s.restore(&snapshot{})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot snapshot
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return err
+	}
+
+	s.restore(&snapshot)
+	return nil
+}
+
+// Save writes the ledger to path. The file is replaced atomically, so an
+// interrupted save keeps the previous snapshot.
+func (s *Stub) Save(path string) error {
+	data, err := json.MarshalIndent(s.snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
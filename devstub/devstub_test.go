@@ -0,0 +1,145 @@
+package devstub
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// counter stores the number of successful "add" calls, with the count as the
+// endorsement policy of its key, and fails on "fail" after writing, to check
+// that failed writes are discarded.
+type counter struct{}
+
+func (c *counter) Init(stub shim.ChaincodeStubInterface) sc.Response {
+	return shim.Success(nil)
+}
+
+func (c *counter) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+	function, _ := stub.GetFunctionAndParameters()
+	value, _ := stub.GetState("count")
+	value = append(value, '+')
+
+	err := stub.PutState("count", value)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	creator, _ := stub.GetCreator()
+	if len(creator) == 0 {
+		return shim.Error("no creator")
+	}
+
+	if function == "fail" {
+		return shim.Error("failed on request")
+	}
+
+	if function == "transient" {
+		transient, _ := stub.GetTransient()
+		return shim.Success(transient["value"])
+	}
+
+	err = stub.SetStateValidationParameter("count", value)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	stub.SetEvent("added", value)
+	return shim.Success(value)
+}
+
+func newCounterStub(t *testing.T) *Stub {
+	stub := New("counter", new(counter))
+	err := stub.SetIdentity("Org1MSP", "user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stub
+}
+
+func TestInvokeCommitsOnlySuccessfulTransactions(t *testing.T) {
+	t.Log("Test that failed transactions and queries leave the ledger unchanged")
+	stub := newCounterStub(t)
+
+	response, events := stub.Invoke([]string{"add"})
+	if response.GetStatus() != shim.OK || len(events) != 1 || events[0].GetEventName() != "added" {
+		t.Fatalf("unexpected response %v with events %v", response, events)
+	}
+
+	// ---- Negative Cases ----
+	response, events = stub.Invoke([]string{"fail"})
+	if response.GetStatus() != shim.ERROR || len(events) != 0 {
+		t.Fatalf("unexpected response %v with events %v", response, events)
+	}
+
+	response, _ = stub.Query([]string{"add"})
+	if string(response.GetPayload()) != "++" {
+		t.Fatalf("unexpected query payload %q", response.GetPayload())
+	}
+
+	if string(stub.State["count"]) != "+" {
+		t.Fatalf("unexpected count %q", stub.State["count"])
+	}
+}
+
+func TestTransientLastsOneTransaction(t *testing.T) {
+	t.Log("Test that a transient map is passed to the next transaction only")
+	stub := newCounterStub(t)
+
+	stub.SetTransient(map[string][]byte{"value": []byte("secret")})
+	response, _ := stub.Query([]string{"transient"})
+	if string(response.GetPayload()) != "secret" {
+		t.Fatalf("unexpected transient payload %q", response.GetPayload())
+	}
+
+	response, _ = stub.Query([]string{"transient"})
+	if len(response.GetPayload()) != 0 {
+		t.Fatalf("transient map passed to a second transaction: %q", response.GetPayload())
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Log("Test that a saved ledger is loaded with its keys and transaction count")
+	dir, err := ioutil.TempDir("", "devstub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ledger.json")
+
+	stub := newCounterStub(t)
+	err = stub.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub.Invoke([]string{"add"})
+	stub.Invoke([]string{"add"})
+	err = stub.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := newCounterStub(t)
+	err = loaded.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(loaded.State["count"]) != "++" || loaded.Keys.Len() != 1 {
+		t.Fatalf("unexpected ledger %v", loaded.State)
+	}
+
+	policy, _ := loaded.GetStateValidationParameter("count")
+	if string(policy) != "++" {
+		t.Fatalf("unexpected endorsement policy %q", policy)
+	}
+
+	loaded.Invoke([]string{"add"})
+	if loaded.txCount != 3 {
+		t.Fatalf("unexpected transaction count %d", loaded.txCount)
+	}
+}
@@ -2,13 +2,14 @@ package main
 
 import (
 	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/ninjastack101/hyperladger-chaincode/chaincode"
 )
 
 var logger = shim.NewLogger("ninjastackSmartContract")
 
 func main() {
-	logger.SetLevel(shim.LogInfo)
-	if err := shim.Start(new(SmartContract)); err != nil {
+	chaincode.SetLogLevel(shim.LogInfo)
+	if err := shim.Start(new(chaincode.SmartContract)); err != nil {
 		logger.Error("Error starting ninjastackSmartContract - ", err)
 	}
 }